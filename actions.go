@@ -0,0 +1,201 @@
+package hanu
+
+import (
+	"context"
+	"errors"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/socketmode"
+)
+
+// ErrNoView is returned when updating the view of an action that was not
+// taken in a view
+var ErrNoView = errors.New("action was not taken in a view")
+
+// Action is a Block Kit element a user interacted with, e.g. a clicked
+// button or a selected option
+type Action struct {
+	ActionID string
+	BlockID  string
+	// User is the user who took the action
+	User string
+	// TriggerID allows opening a modal in response to the action
+	TriggerID string
+
+	action   *slack.BlockAction
+	callback slack.InteractionCallback
+	bot      *Bot
+	ctx      context.Context
+}
+
+// ActionHandler handles a block action, the interaction is acknowledged
+// before it runs so it may take longer than Slack's three seconds
+type ActionHandler func(a *Action) error
+
+// OnAction adds the handler of block actions with the given action ID, e.g.
+// the action ID of a Button
+func (b *Bot) OnAction(actionID string, h ActionHandler) {
+	b.handleAction(actionID, b.actionHandler(h, func(action *slack.BlockAction) bool {
+		return action.ActionID == actionID
+	}))
+}
+
+// OnBlockAction adds the handler of block actions of elements in the block
+// with the given ID
+func (b *Bot) OnBlockAction(blockID string, h ActionHandler) {
+	b.handleBlock(blockID, b.actionHandler(h, func(action *slack.BlockAction) bool {
+		return action.BlockID == blockID
+	}))
+}
+
+// actionHandler adapts an ActionHandler to handle the first matching action
+// of an interaction
+func (b *Bot) actionHandler(h ActionHandler, matches func(action *slack.BlockAction) bool) interactionFunc {
+	return func(ctx context.Context, cb slack.InteractionCallback, evt *socketmode.Event, client *socketmode.Client) error {
+		for _, action := range cb.ActionCallback.BlockActions {
+			if matches(action) {
+				return h(&Action{
+					ActionID:  action.ActionID,
+					BlockID:   action.BlockID,
+					User:      cb.User.ID,
+					TriggerID: cb.TriggerID,
+					action:    action,
+					callback:  cb,
+					bot:       b,
+					ctx:       ctx,
+				})
+			}
+		}
+
+		return nil
+	}
+}
+
+// Context returns the context of the interaction, it is cancelled when the
+// bot stops listening or shuts down
+func (a *Action) Context() context.Context {
+	return a.ctx
+}
+
+// Value returns the value of the element, e.g. the value of a clicked button
+// or of the selected option, user, channel, date or time
+func (a *Action) Value() string {
+	return actionValue(a.action)
+}
+
+// Values returns the values selected in a multi select element
+func (a *Action) Values() []string {
+	return actionValues(a.action)
+}
+
+// BlockAction returns the action as received from Slack
+func (a *Action) BlockAction() slack.BlockAction {
+	return *a.action
+}
+
+// Callback returns the interaction as received from Slack
+func (a *Action) Callback() slack.InteractionCallback {
+	return a.callback
+}
+
+// Message returns the message the action was taken in, it is nil if the
+// action was taken in a view or an ephemeral message
+func (a *Action) Message() *SentMessage {
+	c := a.callback.Container
+	if c.Type != "message" || c.IsEphemeral {
+		return nil
+	}
+
+	return a.bot.postedMessage(c.ChannelID, c.MessageTs, c.ThreadTs).WithContext(a.ctx)
+}
+
+// View returns the view the action was taken in, it is nil if the action was
+// taken in a message
+func (a *Action) View() *slack.View {
+	if a.callback.Container.Type != "view" {
+		return nil
+	}
+
+	return &a.callback.View
+}
+
+// UpdateMessage replaces the text of the message the action was taken in
+func (a *Action) UpdateMessage(text string, args ...interface{}) error {
+	return a.Message().Update(text, args...)
+}
+
+// UpdateMessageBlocks replaces the message the action was taken in with a
+// Block Kit message, e.g. to remove the buttons once one was clicked
+func (a *Action) UpdateMessageBlocks(blocks *Blocks) error {
+	return a.Message().UpdateBlocks(blocks)
+}
+
+// UpdateView replaces the view the action was taken in, it fails if the view
+// changed since the action was taken. The private metadata is kept unless
+// the new view has its own.
+func (a *Action) UpdateView(view slack.ModalViewRequest) error {
+	v := a.View()
+	if v == nil {
+		return ErrNoView
+	}
+
+	_, err := a.bot.UpdateView(a.ctx, keepMetadata(v, view), v.ID, v.Hash)
+	return err
+}
+
+// PushView shows a view on top of the view the action was taken in
+func (a *Action) PushView(view slack.ModalViewRequest) error {
+	v := a.View()
+	if v == nil {
+		return ErrNoView
+	}
+
+	_, err := a.bot.PushView(a.ctx, a.TriggerID, keepMetadata(v, view))
+	return err
+}
+
+// Metadata decodes the private metadata of the view the action was taken in
+// into v
+func (a *Action) Metadata(v interface{}) error {
+	return PrivateMetadata(a.View(), v)
+}
+
+// actionValue returns the value of an element, e.g. the value of a button,
+// a text input or the selected option, user, channel, date or time
+func actionValue(action *slack.BlockAction) string {
+	for _, v := range []string{
+		action.Value,
+		action.SelectedOption.Value,
+		action.SelectedUser,
+		action.SelectedChannel,
+		action.SelectedConversation,
+		action.SelectedDate,
+		action.SelectedTime,
+	} {
+		if v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// actionValues returns the values selected in a multi select element
+func actionValues(action *slack.BlockAction) []string {
+	switch {
+	case len(action.SelectedOptions) > 0:
+		values := make([]string, len(action.SelectedOptions))
+		for i, option := range action.SelectedOptions {
+			values[i] = option.Value
+		}
+		return values
+	case len(action.SelectedUsers) > 0:
+		return action.SelectedUsers
+	case len(action.SelectedChannels) > 0:
+		return action.SelectedChannels
+	case len(action.SelectedConversations) > 0:
+		return action.SelectedConversations
+	}
+
+	return nil
+}
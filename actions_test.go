@@ -0,0 +1,152 @@
+package hanu_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/ChrisMcKee/hanu"
+	"github.com/ChrisMcKee/hanu/hanutest"
+	"github.com/slack-go/slack"
+)
+
+func TestOnActionInMessage(t *testing.T) {
+	h := hanutest.New(t)
+	errs := make(chan error, 1)
+	h.Bot.OnAction("approve", func(a *hanu.Action) error {
+		if a.Value() != "api" || a.User != "U1" || a.BlockID != "deploy" || a.View() != nil {
+			t.Errorf("action should be the approve button, got %+v", a)
+		}
+
+		errs <- a.UpdateMessageBlocks(hanu.NewBlocks().Section("Deploying *%s*, approved by <@%s>", a.Value(), a.User))
+		return nil
+	})
+
+	h.Interaction(slack.InteractionCallback{
+		Type:      slack.InteractionTypeBlockActions,
+		User:      slack.User{ID: "U1"},
+		Container: slack.Container{Type: "message", ChannelID: "C1", MessageTs: "1600000000.000001"},
+		ActionCallback: slack.ActionCallbacks{BlockActions: []*slack.BlockAction{
+			{ActionID: "approve", BlockID: "deploy", Value: "api"},
+		}},
+	}).Ack()
+
+	if err := <-errs; err != nil {
+		t.Fatalf("message should be updated, got %v", err)
+	}
+
+	calls := h.Server.Calls("chat.update")
+	if len(calls) != 1 {
+		t.Fatalf("message should be updated once, was updated %d times", len(calls))
+	}
+
+	v := calls[0].Values
+	if v.Get("channel") != "C1" || v.Get("ts") != "1600000000.000001" || v.Get("text") != "Deploying *api*, approved by <@U1>" {
+		t.Errorf("the message the action was taken in should be updated, got %v", v)
+	}
+}
+
+func TestOnActionIsAcknowledgedFirst(t *testing.T) {
+	h := hanutest.New(t)
+	done := make(chan struct{})
+	h.Bot.OnAction("deploy", func(a *hanu.Action) error {
+		<-done
+		return nil
+	})
+	defer close(done)
+
+	if _, ok := h.Interaction(blockAction("deploy", "")).Acked(hanutest.Timeout); !ok {
+		t.Error("action should be acknowledged before its handler returns")
+	}
+}
+
+func TestOnBlockActionValues(t *testing.T) {
+	h := hanutest.New(t)
+	actions := make(chan *hanu.Action, 2)
+	h.Bot.OnBlockAction("settings", func(a *hanu.Action) error {
+		actions <- a
+		return nil
+	})
+
+	h.Interaction(slack.InteractionCallback{
+		Type: slack.InteractionTypeBlockActions,
+		ActionCallback: slack.ActionCallbacks{BlockActions: []*slack.BlockAction{
+			{ActionID: "env", BlockID: "settings", SelectedOption: slack.OptionBlockObject{Value: "prod"}},
+		}},
+	}).Ack()
+	h.Interaction(slack.InteractionCallback{
+		Type: slack.InteractionTypeBlockActions,
+		ActionCallback: slack.ActionCallbacks{BlockActions: []*slack.BlockAction{
+			{ActionID: "reviewers", BlockID: "settings", SelectedUsers: []string{"U1", "U2"}},
+		}},
+	}).Ack()
+
+	if a := <-actions; a.ActionID != "env" || a.Value() != "prod" {
+		t.Errorf("selected option should be the value, got %s", a.Value())
+	}
+	if a := <-actions; a.ActionID != "reviewers" || !reflect.DeepEqual(a.Values(), []string{"U1", "U2"}) {
+		t.Errorf("selected users should be the values, got %v", a.Values())
+	}
+}
+
+func TestOnActionInView(t *testing.T) {
+	h := hanutest.New(t)
+	errs := make(chan error, 1)
+	h.Bot.OnAction("env", func(a *hanu.Action) error {
+		if a.Message() != nil {
+			t.Error("action taken in a view should have no message")
+		}
+
+		errs <- a.UpdateView(slack.ModalViewRequest{Type: slack.VTModal, CallbackID: "deploy_prod"})
+		return nil
+	})
+
+	h.Interaction(slack.InteractionCallback{
+		Type:      slack.InteractionTypeBlockActions,
+		Container: slack.Container{Type: "view", ViewID: "V1"},
+		View:      slack.View{ID: "V1", Hash: "h1", CallbackID: "deploy"},
+		ActionCallback: slack.ActionCallbacks{BlockActions: []*slack.BlockAction{
+			{ActionID: "env", SelectedOption: slack.OptionBlockObject{Value: "prod"}},
+		}},
+	}).Ack()
+
+	if err := <-errs; err != nil {
+		t.Fatalf("view should be updated, got %v", err)
+	}
+
+	calls := h.Server.Calls("views.update")
+	if len(calls) != 1 {
+		t.Fatalf("view should be updated once, was updated %d times", len(calls))
+	}
+
+	var req struct {
+		ViewID string                 `json:"view_id"`
+		Hash   string                 `json:"hash"`
+		View   slack.ModalViewRequest `json:"view"`
+	}
+	if err := json.Unmarshal(calls[0].Body, &req); err != nil {
+		t.Fatalf("views.update should be sent JSON: %v", err)
+	}
+	if req.ViewID != "V1" || req.Hash != "h1" || req.View.CallbackID != "deploy_prod" {
+		t.Errorf("view V1 should be updated if unchanged, got %+v", req)
+	}
+}
+
+func TestUpdateViewWithoutView(t *testing.T) {
+	h := hanutest.New(t)
+	errs := make(chan error, 1)
+	h.Bot.OnAction("approve", func(a *hanu.Action) error {
+		errs <- a.UpdateView(slack.ModalViewRequest{})
+		return nil
+	})
+
+	h.Interaction(slack.InteractionCallback{
+		Type:           slack.InteractionTypeBlockActions,
+		Container:      slack.Container{Type: "message", ChannelID: "C1", MessageTs: "1600000000.000001"},
+		ActionCallback: slack.ActionCallbacks{BlockActions: []*slack.BlockAction{{ActionID: "approve"}}},
+	}).Ack()
+
+	if err := <-errs; err != hanu.ErrNoView {
+		t.Errorf("updating the view of an action taken in a message should fail, got %v", err)
+	}
+}
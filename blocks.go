@@ -0,0 +1,149 @@
+package hanu
+
+import (
+	"fmt"
+
+	"github.com/slack-go/slack"
+)
+
+// Button is a button in a message, its action ID identifies the button in
+// the interaction sent when it is clicked
+type Button struct {
+	ActionID string
+	Text     string
+	Value    string
+	// URL opens a page instead of only sending an interaction
+	URL string
+	// Style is either slack.StylePrimary, slack.StyleDanger or empty
+	Style slack.Style
+}
+
+// element converts the button to a Block Kit element
+func (b Button) element() *slack.ButtonBlockElement {
+	btn := slack.NewButtonBlockElement(b.ActionID, b.Value, slack.NewTextBlockObject(slack.PlainTextType, b.Text, true, false))
+	if b.URL != "" {
+		btn = btn.WithURL(b.URL)
+	}
+	if b.Style != "" {
+		btn = btn.WithStyle(b.Style)
+	}
+
+	return btn
+}
+
+// Blocks builds a Block Kit message, e.g.
+//
+//	hanu.NewBlocks().Header("Deploy").Section("Deploy *api* to prod?").Buttons(approve, cancel)
+type Blocks struct {
+	blocks []slack.Block
+	text   string
+}
+
+// NewBlocks creates an empty Block Kit message
+func NewBlocks() *Blocks {
+	return &Blocks{}
+}
+
+// Text sets the plain text shown in notifications, by default it is the
+// text of the first section
+func (b *Blocks) Text(text string, a ...interface{}) *Blocks {
+	b.text = fmt.Sprintf(text, a...)
+	return b
+}
+
+// Header adds a header with a large plain text
+func (b *Blocks) Header(text string, a ...interface{}) *Blocks {
+	return b.Block(slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, fmt.Sprintf(text, a...), true, false)))
+}
+
+// Section adds a section with formatted text
+func (b *Blocks) Section(text string, a ...interface{}) *Blocks {
+	return b.Block(slack.NewSectionBlock(markdown(fmt.Sprintf(text, a...)), nil, nil))
+}
+
+// SectionWithButton adds a section with formatted text and a button next to it
+func (b *Blocks) SectionWithButton(text string, button Button) *Blocks {
+	return b.Block(slack.NewSectionBlock(markdown(text), nil, slack.NewAccessory(button.element())))
+}
+
+// Fields adds a section showing the formatted texts in two columns
+func (b *Blocks) Fields(fields ...string) *Blocks {
+	objects := make([]*slack.TextBlockObject, len(fields))
+	for i, field := range fields {
+		objects[i] = markdown(field)
+	}
+
+	return b.Block(slack.NewSectionBlock(nil, objects, nil))
+}
+
+// Buttons adds a row of buttons
+func (b *Blocks) Buttons(buttons ...Button) *Blocks {
+	elements := make([]slack.BlockElement, len(buttons))
+	for i, button := range buttons {
+		elements[i] = button.element()
+	}
+
+	return b.Block(slack.NewActionBlock("", elements...))
+}
+
+// Context adds a line of small formatted texts
+func (b *Blocks) Context(texts ...string) *Blocks {
+	elements := make([]slack.MixedElement, len(texts))
+	for i, text := range texts {
+		elements[i] = markdown(text)
+	}
+
+	return b.Block(slack.NewContextBlock("", elements...))
+}
+
+// Divider adds a horizontal line
+func (b *Blocks) Divider() *Blocks {
+	return b.Block(slack.NewDividerBlock())
+}
+
+// Image adds an image
+func (b *Blocks) Image(url, altText string) *Blocks {
+	return b.Block(slack.NewImageBlock(url, altText, "", nil))
+}
+
+// Block adds any other Block Kit block
+func (b *Blocks) Block(block slack.Block) *Blocks {
+	b.blocks = append(b.blocks, block)
+	return b
+}
+
+// Build returns the blocks
+func (b *Blocks) Build() []slack.Block {
+	return b.blocks
+}
+
+// FallbackText returns the plain text shown in notifications
+func (b *Blocks) FallbackText() string {
+	if b.text != "" {
+		return b.text
+	}
+
+	for _, block := range b.blocks {
+		if section, ok := block.(*slack.SectionBlock); ok && section.Text != nil {
+			return section.Text.Text
+		}
+	}
+
+	return ""
+}
+
+func markdown(text string) *slack.TextBlockObject {
+	return slack.NewTextBlockObject(slack.MarkdownType, text, false, false)
+}
+
+// SayBlocks will cause the bot to post a Block Kit message in the specified
+// channel
+func (b *Bot) SayBlocks(channel string, blocks *Blocks) *SentMessage {
+	return b.sent(b.send(Message{ChannelID: channel, Message: blocks.FallbackText()}, slack.MsgOptionBlocks(blocks.Build()...)))
+}
+
+// SayBlocksInThread will cause the bot to post a Block Kit message in the
+// thread of the message with the given timestamp
+func (b *Bot) SayBlocksInThread(channel, threadTS string, blocks *Blocks) *SentMessage {
+	return b.sent(b.send(Message{ChannelID: channel, ThreadTimeStamp: threadTS, Message: blocks.FallbackText()}, slack.MsgOptionBlocks(blocks.Build()...)))
+}
@@ -0,0 +1,80 @@
+package hanu_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ChrisMcKee/hanu"
+	"github.com/ChrisMcKee/hanu/hanutest"
+	"github.com/slack-go/slack"
+)
+
+func TestBlocksFallbackText(t *testing.T) {
+	blocks := hanu.NewBlocks().Header("Deploy").Section("Deploy *%s*?", "api")
+	if text := blocks.FallbackText(); text != "Deploy *api*?" {
+		t.Errorf("fallback text should be the first section, is \"%s\"", text)
+	}
+
+	if text := blocks.Text("Deploy request").FallbackText(); text != "Deploy request" {
+		t.Errorf("fallback text should be the text set, is \"%s\"", text)
+	}
+}
+
+func TestBotReplyBlocks(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.Command("deploy <service>", func(conv hanu.Convo) {
+		svc, _ := conv.String("service")
+		conv.ReplyBlocks(hanu.NewBlocks().
+			Header("Deploy").
+			Section("Deploy *%s* to prod?", svc).
+			Divider().
+			Buttons(
+				hanu.Button{ActionID: "approve", Text: "Approve", Value: svc, Style: slack.StylePrimary},
+				hanu.Button{ActionID: "cancel", Text: "Cancel", Value: svc},
+			).
+			Context("requested by <@" + conv.Message().User() + ">"))
+	})
+
+	h.Message("U1", "C1", "deploy api")
+
+	post := h.NextPost()
+	if post.Channel != "C1" || post.Text != "Deploy *api* to prod?" {
+		t.Errorf("blocks should be posted to C1 with a fallback text, got \"%s\" in %s", post.Text, post.Channel)
+	}
+
+	var blocks slack.Blocks
+	if err := json.Unmarshal([]byte(post.Values.Get("blocks")), &blocks); err != nil {
+		t.Fatalf("blocks should be posted: %v", err)
+	}
+
+	types := []slack.MessageBlockType{slack.MBTHeader, slack.MBTSection, slack.MBTDivider, slack.MBTAction, slack.MBTContext}
+	if len(blocks.BlockSet) != len(types) {
+		t.Fatalf("%d blocks should be posted, got %d", len(types), len(blocks.BlockSet))
+	}
+	for i, typ := range types {
+		if blocks.BlockSet[i].BlockType() != typ {
+			t.Errorf("block %d should be a %s, is a %s", i, typ, blocks.BlockSet[i].BlockType())
+		}
+	}
+
+	actions := blocks.BlockSet[3].(*slack.ActionBlock)
+	approve := actions.Elements.ElementSet[0].(*slack.ButtonBlockElement)
+	if approve.ActionID != "approve" || approve.Value != "api" || approve.Style != slack.StylePrimary {
+		t.Errorf("approve button should be posted, got %+v", approve)
+	}
+}
+
+func TestBotReplyBlocksInThread(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.SetThreadReplies(true)
+	h.Bot.Command("status", func(conv hanu.Convo) {
+		conv.ReplyBlocks(hanu.NewBlocks().Section("all good"))
+	})
+
+	ts := h.Message("U1", "C1", "root")
+	h.ThreadMessage("U1", "C1", ts, "status")
+
+	if post := h.NextPost(); post.ThreadTimestamp != ts {
+		t.Errorf("blocks should be posted in thread %s, got \"%s\"", ts, post.ThreadTimestamp)
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
+	"sync"
 
 	"github.com/slack-go/slack"
 	"github.com/slack-go/slack/slackevents"
@@ -13,14 +15,32 @@ import (
 
 // Bot is the main object
 type Bot struct {
-	SocketClient      *socketmode.Client
-	ID                string
-	Commands          []CommandInterface
-	ReplyOnly         bool
-	CmdPrefix         string
-	socketHandler     *socketmode.SocketmodeHandler
-	unknownCmdHandler Handler
-	listenerEnabled   bool
+	SocketClient       *socketmode.Client
+	ID                 string
+	Commands           []CommandInterface
+	ReplyOnly          bool
+	ThreadReplies      bool
+	ephemeralHelp      bool
+	ackReactions       bool
+	CmdPrefix          string
+	router             *eventRouter
+	commandRouter      commandRouter
+	acks               acks
+	unknownCmdHandler  Handler
+	listenerEnabled    bool
+	listenOnce         sync.Once
+	prompts            prompts
+	reactionHandlers   []reactionHandler
+	interactions       interactionRouter
+	middleware         []Middleware
+	errorHandler       ErrorHandler
+	errorReply         string
+	denyReply          string
+	groups             groupCache
+	logger             *slog.Logger
+	metrics            *Metrics
+	outbox             *outbox
+	sendFailureHandler SendFailureHandler
 }
 
 // New creates a new bot
@@ -35,18 +55,7 @@ func New(token string, appToken string) (*Bot, error) {
 		socketmode.OptionDebug(false),
 	)
 
-	r, e := api.AuthTest()
-	if e != nil {
-		return nil, e
-	}
-
-	bot := &Bot{
-		SocketClient:  socketClient,
-		ID:            r.UserID,
-		socketHandler: socketmode.NewSocketmodeHandler(socketClient),
-	}
-
-	return bot, nil
+	return NewWithClient(socketClient)
 }
 
 // NewDebug New creates a new bot with Debug
@@ -63,16 +72,30 @@ func NewDebug(token string, appToken string) (*Bot, error) {
 		socketmode.OptionLog(log.New(os.Stdout, "socketmode: ", log.Lshortfile|log.LstdFlags)),
 	)
 
-	r, e := api.AuthTest()
+	bot, err := NewWithClient(socketClient)
+	if err != nil {
+		return nil, err
+	}
+
+	return bot.SetLogger(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))), nil
+}
+
+// NewWithClient creates a new bot using an already configured socket mode client,
+// e.g. one pointed at a fake Slack API in tests. Bots only served over HTTP
+// do not need an app-level token.
+func NewWithClient(socketClient *socketmode.Client) (*Bot, error) {
+	r, e := socketClient.AuthTest()
 	if e != nil {
 		return nil, e
 	}
 
 	bot := &Bot{
-		SocketClient:  socketClient,
-		ID:            r.UserID,
-		socketHandler: socketmode.NewSocketmodeHandler(socketClient),
+		SocketClient: socketClient,
+		ID:           r.UserID,
+		router:       newEventRouter(),
+		denyReply:    "Sorry, you are not allowed to run this command.",
 	}
+	bot.outbox = newOutbox(socketClient.PostMessageContext, bot.sendFailed)
 
 	return bot, nil
 }
@@ -90,16 +113,28 @@ func (b *Bot) SetReplyOnly(ro bool) *Bot {
 	return b
 }
 
-// Process incoming message
-func (b *Bot) process(msg Message) {
+// SetThreadReplies will make replies to commands invoked inside a thread
+// answer in that same thread
+func (b *Bot) SetThreadReplies(tr bool) *Bot {
+	b.ThreadReplies = tr
+	return b
+}
+
+// Process incoming message, ctx is passed on to the conversation
+func (b *Bot) process(ctx context.Context, msg Message) {
 	// Strip @BotName from public message
 	msg.SetText(msg.StripMention(b.ID))
 	// Strip Slack's link markup
 	msg.SetText(msg.StripLinkMarkup())
 
+	// Answers to questions go to the handler waiting for them
+	if b.prompts.answer(msg) {
+		return
+	}
+
 	// Only send auto-generated help command list if directly mentioned
 	if msg.IsRelevantFor(b.ID) && msg.IsHelpRequest() {
-		b.sendHelp(msg)
+		b.sendHelp(ctx, msg)
 		return
 	}
 
@@ -108,26 +143,70 @@ func (b *Bot) process(msg Message) {
 		return
 	}
 
-	handled := b.searchCommand(msg)
-	if !handled && b.ReplyOnly {
-		if b.unknownCmdHandler != nil {
-			b.unknownCmdHandler(NewConversation(dummyMatch{}, msg, b))
+	if b.searchCommand(ctx, msg) {
+		return
+	}
+	b.metrics.commandUnmatched()
+
+	// Explain how to use a command if the request starts like one
+	if b.isCommandRequest(msg) {
+		if cmds := b.nearMatches(ctx, msg); len(cmds) > 0 {
+			b.reply(msg, "Usage:\n"+b.buildUsageText(cmds))
+			return
+		}
+	}
+
+	if b.ReplyOnly && b.unknownCmdHandler != nil {
+		b.log().Debug("dispatching unknown command", messageAttrs(msg)...)
+		b.dispatch("", b.unknownCmdHandler, NewConversation(ctx, dummyMatch{}, msg, b))
+		return
+	}
+
+	if b.isCommandRequest(msg) {
+		if cmds := b.suggestions(ctx, msg); len(cmds) > 0 {
+			b.reply(msg, "Did you mean:\n"+b.buildUsageText(cmds))
 		}
 	}
 }
 
 // Search for a command matching the message
-func (b *Bot) searchCommand(msg Message) bool {
-	var cmd CommandInterface
-
-	for i := 0; i < len(b.Commands); i++ {
-		cmd = b.Commands[i]
+func (b *Bot) searchCommand(ctx context.Context, msg Message) bool {
+	// usage explains the first command whose option was not valid, unless a
+	// later command matches
+	var usage string
+
+	for _, cmd := range b.commandRouter.candidates(b.Commands, msg.Text()) {
+		if !hasWordCount(cmd, msg.Text()) {
+			continue
+		}
 
 		match, err := cmd.Get().Match(msg.Text())
-		if err == nil {
-			cmd.Handle(NewConversation(match, msg, b))
-			return true
+		if err != nil {
+			continue
 		}
+
+		if param, value, invalid := invalidOption(cmd, match); invalid {
+			if usage == "" && b.isCommandRequest(msg) {
+				usage = "`" + value + "` is not one of `" + param.Name() + "`\nUsage:\n" + b.BuildCommandHelpText(cmd)
+			}
+			continue
+		}
+
+		ctx, cancel := commandContext(ctx, cmd)
+		defer cancel()
+		ctx, failed := withOutcome(ctx)
+
+		b.log().Debug("dispatching command", append(messageAttrs(msg), "command", cmd.Get().Text())...)
+		b.metrics.commandMatched(cmd)
+		b.acknowledge(msg, failed, func() {
+			b.dispatch(cmd.Get().Text(), b.commandHandler(cmd), NewConversation(ctx, match, msg, b))
+		})
+		return true
+	}
+
+	if usage != "" {
+		b.reply(msg, usage)
+		return true
 	}
 
 	return false
@@ -139,126 +218,209 @@ func (b *Bot) Channel(id string) Channel {
 }
 
 // Say will cause the bot to say something in the specified channel
-func (b *Bot) Say(channel, msg string, a ...interface{}) {
-	b.send(Message{ChannelID: channel, Message: fmt.Sprintf(msg, a...)})
+func (b *Bot) Say(channel, msg string, a ...interface{}) *SentMessage {
+	return b.sent(b.send(Message{ChannelID: channel, Message: fmt.Sprintf(msg, a...)}))
 }
 
-func (b *Bot) send(msg MessageInterface) {
-	_, _, err := b.SocketClient.PostMessage(
-		msg.Channel(),
-		slack.MsgOptionText(msg.Text(), false))
-	if err != nil {
-		fmt.Printf("failed posting message: %v", err)
+// SayInThread will cause the bot to say something in the thread of the
+// message with the given timestamp
+func (b *Bot) SayInThread(channel, threadTS, msg string, a ...interface{}) *SentMessage {
+	return b.sent(b.send(Message{ChannelID: channel, ThreadTimeStamp: threadTS, Message: fmt.Sprintf(msg, a...)}))
+}
+
+// send queues a message to be posted with its text and any further options,
+// the returned message is done once it was posted or failed
+func (b *Bot) send(msg MessageInterface, extra ...slack.MsgOption) *outgoing {
+	options := []slack.MsgOption{slack.MsgOptionText(msg.Text(), false)}
+	if msg.IsThreaded() {
+		options = append(options, slack.MsgOptionTS(msg.ThreadTimestamp()))
 	}
+	options = append(options, extra...)
+
+	return b.outbox.enqueue(msg, options)
+}
+
+// sent returns the handle of a queued message
+func (b *Bot) sent(out *outgoing) *SentMessage {
+	return &SentMessage{bot: b, out: out}
 }
 
 // BuildHelpText will build the help text
 func (b *Bot) BuildHelpText() string {
+	return b.buildHelpText(func(cmd CommandInterface) bool {
+		return true
+	})
+}
+
+// BuildHelpTextFor will build the help text listing only the commands the
+// sender of the message is allowed to run
+func (b *Bot) BuildHelpTextFor(msg MessageInterface) string {
+	return b.helpTextFor(context.Background(), msg)
+}
+
+func (b *Bot) helpTextFor(ctx context.Context, msg MessageInterface) string {
+	members := b.groupMembers(ctx)
+
+	return b.buildHelpText(func(cmd CommandInterface) bool {
+		return b.isListed(cmd, msg, members)
+	})
+}
+
+func (b *Bot) buildHelpText(show func(cmd CommandInterface) bool) string {
 	var cmd CommandInterface
 	help := "The available commands are:\n\n"
+	listed := make(map[*CommandGroup]bool)
 
 	for i := 0; i < len(b.Commands); i++ {
 		cmd = b.Commands[i]
+		if gc, ok := cmd.(groupCommand); ok {
+			// list the whole group where its first command was registered
+			root := gc.group.root()
+			if !listed[root] {
+				listed[root] = true
+				help = help + b.buildGroupHelpText(root, show, 0)
+			}
+			continue
+		}
 
-		help = help + "`" + b.CmdPrefix + cmd.Get().Text() + "`"
-		if cmd.Description() != "" {
-			help = help + " *–* " + cmd.Description()
+		if !show(cmd) {
+			continue
 		}
 
-		help = help + "\n"
+		help = help + helpLine(b.CmdPrefix, cmd)
 	}
 
 	return help
 }
 
-// sendHelp will send help to the channel and user in the given message
-func (b *Bot) sendHelp(msg MessageInterface) {
-	help := b.BuildHelpText()
+// helpLine will build the line listing a command in the help text
+func helpLine(prefix string, cmd CommandInterface) string {
+	help := "`" + prefix + cmd.Get().Text() + "`"
+	if cmd.Description() != "" {
+		help = help + " *–* " + cmd.Description()
+	}
+
+	return help + "\n"
+}
 
-	if !msg.IsDirectMessage() {
-		help = "<@" + msg.User() + ">: " + help
+// sendHelp will send help to the channel and user in the given message,
+// the detailed help of the commands if asked for a specific one
+func (b *Bot) sendHelp(ctx context.Context, msg Message) {
+	help := b.helpTextFor(ctx, msg)
+
+	if topic := helpTopic(msg.Text()); topic != "" {
+		if cmds := b.commandsFor(ctx, topic, msg); len(cmds) > 0 {
+			help = b.buildUsageText(cmds)
+
+			if g, ok := b.groupFor(topic); ok && g.Description() != "" {
+				help = "`" + g.Name() + "` *–* " + g.Description() + "\n\n" + help
+			}
+		}
 	}
 
-	b.Say(msg.Channel(), "%s", help)
+	if b.ephemeralHelp {
+		if err := NewConversation(context.Background(), dummyMatch{}, msg, b).ReplyEphemeral("%s", help); err != nil {
+			b.log().Error("failed sending help", append(messageAttrs(msg), "error", err)...)
+		}
+		return
+	}
+
+	b.reply(msg, help)
 }
 
-// Listen for message on socket
-func (b *Bot) Listen(ctx context.Context) {
-	b.socketHandler.Handle(socketmode.EventTypeConnecting, middlewareConnecting)
-	b.socketHandler.Handle(socketmode.EventTypeConnectionError, middlewareConnectionError)
-	b.socketHandler.Handle(socketmode.EventTypeConnected, middlewareConnected)
+// reply answers the message like a command's conversation would
+func (b *Bot) reply(msg Message, text string) {
+	NewConversation(context.Background(), dummyMatch{}, msg, b).Reply("%s", text)
+}
 
-	// Handle a specific event from EventsAPI
-	b.socketHandler.HandleEvents(slackevents.AppMention, middlewareAppMentionEventWithBot(b))
-	b.socketHandler.HandleEvents(slackevents.Message, middlewareMessageEventWithBot(b))
+// Listen for message on socket, use HTTPHandler to receive events over
+// HTTP instead
+func (b *Bot) Listen(ctx context.Context) {
+	b.listen()
 
-	b.listenerEnabled = true
-	b.socketHandler.RunEventLoopContext(ctx)
+	go b.runEventLoop(ctx)
+	b.SocketClient.RunContext(ctx)
 }
 
-func middlewareAppMentionEventWithBot(b *Bot) socketmode.SocketmodeHandlerFunc {
-	return func(evt *socketmode.Event, client *socketmode.Client) {
-		middlewareAppMentionEvent(evt, client, b)
+func middlewareAppMentionEventWithBot(b *Bot) eventHandler {
+	return func(ctx context.Context, evt *socketmode.Event, client *socketmode.Client) {
+		middlewareAppMentionEvent(ctx, evt, client, b)
 	}
 }
 
-func middlewareAppMentionEvent(evt *socketmode.Event, client *socketmode.Client, b *Bot) {
+func middlewareAppMentionEvent(ctx context.Context, evt *socketmode.Event, client *socketmode.Client, b *Bot) {
 	eventsAPIEvent, ok := evt.Data.(slackevents.EventsAPIEvent)
 	if !ok {
-		client.Debugf("Ignored %+v\n", evt)
+		b.log().Debug("ignored event", "event_type", evt.Type)
 		return
 	}
 
-	client.Ack(*evt.Request)
+	b.Ack(*evt.Request)
 
 	ev, ok := eventsAPIEvent.InnerEvent.Data.(*slackevents.AppMentionEvent)
 	if !ok {
-		client.Debugf("Ignored %+v\n", ev)
+		b.log().Debug("ignored event", "event_type", evt.Type, "inner_event_type", eventsAPIEvent.InnerEvent.Type)
 		return
 	}
 
-	go b.process(NewMentionMessage(ev))
+	b.process(ctx, NewMentionMessage(ev))
 }
 
-func middlewareMessageEventWithBot(b *Bot) socketmode.SocketmodeHandlerFunc {
-	return func(evt *socketmode.Event, client *socketmode.Client) {
-		middlewareMessageEvent(evt, client, b)
+func middlewareMessageEventWithBot(b *Bot) eventHandler {
+	return func(ctx context.Context, evt *socketmode.Event, client *socketmode.Client) {
+		middlewareMessageEvent(ctx, evt, client, b)
 	}
 }
 
-func middlewareMessageEvent(evt *socketmode.Event, client *socketmode.Client, b *Bot) {
+func middlewareMessageEvent(ctx context.Context, evt *socketmode.Event, client *socketmode.Client, b *Bot) {
 	eventsAPIEvent, ok := evt.Data.(slackevents.EventsAPIEvent)
 	if !ok {
-		client.Debugf("Ignored %+v\n", evt)
+		b.log().Debug("ignored event", "event_type", evt.Type)
 		return
 	}
 
-	client.Ack(*evt.Request)
+	b.Ack(*evt.Request)
 
 	ev, ok := eventsAPIEvent.InnerEvent.Data.(*slackevents.MessageEvent)
 	if !ok {
-		client.Debugf("Ignored %+v\n", ev)
+		b.log().Debug("ignored event", "event_type", evt.Type, "inner_event_type", eventsAPIEvent.InnerEvent.Type)
 		return
 	}
 
-	go b.process(NewMessage(ev))
+	b.process(ctx, NewMessage(ev))
 }
 
-func middlewareConnecting(evt *socketmode.Event, client *socketmode.Client) {
-	client.Debugf("Connecting to Slack with Socket Mode...")
+func middlewareConnecting(b *Bot) eventHandler {
+	return func(ctx context.Context, evt *socketmode.Event, client *socketmode.Client) {
+		b.metrics.socketEvent("connecting")
+		b.log().Debug("connecting to Slack with Socket Mode")
+	}
 }
 
-func middlewareConnectionError(evt *socketmode.Event, client *socketmode.Client) {
-	client.Debugf("Connection failed. Retrying later...")
+func middlewareConnectionError(b *Bot) eventHandler {
+	return func(ctx context.Context, evt *socketmode.Event, client *socketmode.Client) {
+		b.metrics.socketEvent("error")
+		b.log().Warn("connection failed, retrying later", "event_type", evt.Type)
+	}
 }
 
-func middlewareConnected(evt *socketmode.Event, client *socketmode.Client) {
-	client.Debugf("Connected to Slack with Socket Mode.")
+func middlewareConnected(b *Bot) eventHandler {
+	return func(ctx context.Context, evt *socketmode.Event, client *socketmode.Client) {
+		b.metrics.socketEvent("connected")
+		b.log().Info("connected to Slack with Socket Mode")
+	}
 }
 
 // Command adds a new command with custom handler
 func (b *Bot) Command(cmd string, handler Handler) {
 	b.Commands = append(b.Commands, NewCommand(b.CmdPrefix+cmd, "", handler))
+	b.commandRouter.invalidate()
+}
+
+// CommandE adds a new command with a handler that can fail
+func (b *Bot) CommandE(cmd string, handler HandlerE) {
+	b.Commands = append(b.Commands, NewCommandE(b.CmdPrefix+cmd, "", handler))
+	b.commandRouter.invalidate()
 }
 
 // UnknownCommand will be called when the user calls a command that is unknown,
@@ -270,20 +432,32 @@ func (b *Bot) UnknownCommand(h Handler) {
 // Register registers a Command
 func (b *Bot) Register(cmd CommandInterface) {
 	b.Commands = append(b.Commands, cmd)
+	b.commandRouter.invalidate()
 }
 
 func (b *Bot) RegisterSlashCommand(cmd string, handler func(evt *socketmode.Event, client *socketmode.Client)) {
 	if b.listenerEnabled {
 		log.Fatal("RegisterSlashCommand must be called before Listen")
 	}
-	b.socketHandler.HandleSlashCommand(cmd, handler)
+	b.handleSlashCommand(cmd, withoutContext(handler))
+}
+
+// handleSlashCommand routes the slash command to the handler
+func (b *Bot) handleSlashCommand(cmd string, h eventHandler) {
+	if _, exist := b.router.slashCommands[cmd]; exist {
+		panic("multiple registrations for command " + cmd)
+	}
+	b.router.slashCommands[cmd] = h
 }
 
+// RegisterInteraction adds a handler of interactions of the given type, the
+// interaction is acknowledged once it returns unless it acknowledged it with
+// Ack
 func (b *Bot) RegisterInteraction(et slack.InteractionType, handler func(evt *socketmode.Event, client *socketmode.Client)) {
 	if b.listenerEnabled {
 		log.Fatal("RegisterSlashCommand must be called before Listen")
 	}
-	b.socketHandler.HandleInteraction(et, handler)
+	b.router.interactions[et] = append(b.router.interactions[et], withoutContext(handler))
 }
 
 func (b *Bot) RegisterEventHandler(et slackevents.EventsAPIType, handler func(evt *socketmode.Event, client *socketmode.Client)) {
@@ -294,5 +468,5 @@ func (b *Bot) RegisterEventHandler(et slackevents.EventsAPIType, handler func(ev
 		log.Fatal("AppMention event type is reserved for Bot")
 		return
 	}
-	b.socketHandler.HandleEvents(et, handler)
+	b.router.eventsAPI[et] = append(b.router.eventsAPI[et], withoutContext(handler))
 }
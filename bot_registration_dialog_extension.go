@@ -1,8 +1,7 @@
 package hanu
 
 import (
-	"fmt"
-	"log"
+	"context"
 
 	"github.com/slack-go/slack"
 	"github.com/slack-go/slack/socketmode"
@@ -26,89 +25,151 @@ type DialogCfg struct {
 	Dialog            DialogEvtHandler
 	SubmissionHandler DialogEvtHandler
 	CallbackId        string
+
+	// OnClose is called when the user cancels a dialog or closes a modal,
+	// modals are only reported if the view has NotifyOnClose set
+	OnClose DialogEvtHandler
+
+	// View builds the modal opened by the slash command and shortcuts below,
+	// its callback ID defaults to the one SubmissionHandler is routed by
+	View func(t *ModalTrigger) (slack.ModalViewRequest, error)
+	// SlashCommand opens the modal when the command, e.g. /deploy, is used
+	SlashCommand string
+	// Shortcut and MessageShortcut are the callback IDs of the global and
+	// message shortcuts opening the modal
+	Shortcut        string
+	MessageShortcut string
+}
+
+// ModalTrigger is the slash command or shortcut a modal is opened by
+type ModalTrigger struct {
+	// User is the user the modal is opened for
+	User string
+	// Channel is the channel the slash command or message shortcut was used
+	// in, it is empty for global shortcuts
+	Channel string
+	// TriggerID is the trigger ID the modal is opened with
+	TriggerID string
+	// Text is the text after the slash command
+	Text string
+	// Message is the message the message shortcut was used on
+	Message *slack.Message
+
+	ctx context.Context
+}
+
+// Context returns the context of the slash command or shortcut, it is
+// cancelled when the bot stops listening or shuts down
+func (t *ModalTrigger) Context() context.Context {
+	return t.ctx
 }
 
 // RegisterDialogInteraction registers a dialog interaction.
 // Callback ID for launching a dialogue is based on the bot-id and the callbackId;
 // for submission it is based on the user-id and the callbackId
 func (b *Bot) RegisterDialogInteraction(evtHandlerCfg DialogCfg) {
-	if b.listenerEnabled {
-		log.Fatal("RegisterSlashCommand must be called before Listen")
+	if evtHandlerCfg.bound() {
+		panic("only modals can be opened by slash commands and shortcuts, dialog " + evtHandlerCfg.CallbackId)
 	}
-
-	b.RegisterInteraction(slack.InteractionTypeInteractionMessage, func(evt *socketmode.Event, client *socketmode.Client) {
-		callback, ok := evt.Data.(slack.InteractionCallback)
-		if !ok {
-			fmt.Printf("Ignored %+v\n", evt)
-			return
-		}
-
-		switch callback.CallbackID {
-		case b.ID + evtHandlerCfg.CallbackId:
-			err := evtHandlerCfg.Dialog(b, callback, evt, client)
-			if err != nil {
-				fmt.Printf("Error %+v\n", err)
-			}
-			client.Ack(*evt.Request)
-			break
-		}
-	})
-
-	b.RegisterInteraction(slack.InteractionTypeDialogSubmission, func(evt *socketmode.Event, client *socketmode.Client) {
-		callback, ok := evt.Data.(slack.InteractionCallback)
-		if !ok {
-			fmt.Printf("Ignored %+v\n", evt)
-			return
-		}
-
-		switch callback.CallbackID {
-		case callback.User.ID + evtHandlerCfg.CallbackId:
-			err := evtHandlerCfg.SubmissionHandler(b, callback, evt, client)
-			if err != nil {
-				fmt.Printf("Error %+v\n", err)
-			}
-			client.Ack(*evt.Request)
-		}
-	})
+	b.registerDialog(evtHandlerCfg, slack.InteractionTypeDialogSubmission, slack.InteractionTypeDialogCancellation)
 }
 
+// RegisterModalInteraction registers a modal interaction, the modal can be
+// opened by a dialog launcher as well as by the configured slash command and
+// shortcuts
 func (b *Bot) RegisterModalInteraction(evtHandlerCfg DialogCfg) {
-	if b.listenerEnabled {
-		log.Fatal("RegisterSlashCommand must be called before Listen")
+	b.registerDialog(evtHandlerCfg, slack.InteractionTypeViewSubmission, slack.InteractionTypeViewClosed)
+	b.bindModal(evtHandlerCfg)
+}
+
+// bound reports whether the config has a slash command or shortcut
+func (cfg DialogCfg) bound() bool {
+	return cfg.SlashCommand != "" || cfg.Shortcut != "" || cfg.MessageShortcut != ""
+}
+
+// bindModal opens the modal built by the config's View when its slash
+// command or shortcuts are used
+func (b *Bot) bindModal(cfg DialogCfg) {
+	if !cfg.bound() {
+		return
+	}
+	if cfg.View == nil {
+		panic("modal " + cfg.CallbackId + " needs a View to be opened by slash commands and shortcuts")
 	}
 
-	b.RegisterInteraction(slack.InteractionTypeInteractionMessage, func(evt *socketmode.Event, client *socketmode.Client) {
-		callback, ok := evt.Data.(slack.InteractionCallback)
-		if !ok {
-			fmt.Printf("Ignored %+v\n", evt)
-			return
-		}
-
-		switch callback.CallbackID {
-		case b.ID + evtHandlerCfg.CallbackId:
-			err := evtHandlerCfg.Dialog(b, callback, evt, client)
-			if err != nil {
-				fmt.Printf("Error %+v\n", err)
+	if cfg.SlashCommand != "" {
+		b.handleSlashCommand(cfg.SlashCommand, func(ctx context.Context, evt *socketmode.Event, client *socketmode.Client) {
+			cmd, ok := evt.Data.(slack.SlashCommand)
+			if !ok || evt.Request == nil {
+				return
 			}
-			client.Ack(*evt.Request)
-			break
-		}
-	})
-
-	b.RegisterInteraction(slack.InteractionTypeViewSubmission, func(evt *socketmode.Event, client *socketmode.Client) {
-		callback, ok := evt.Data.(slack.InteractionCallback)
-		if !ok {
-			fmt.Printf("Ignored %+v\n", evt)
-			return
-		}
-
-		switch callback.View.CallbackID {
-		case callback.User.ID + evtHandlerCfg.CallbackId:
-			err := evtHandlerCfg.SubmissionHandler(b, callback, evt, client)
-			if err != nil {
-				fmt.Printf("Error %+v\n", err)
+
+			// Acknowledging first keeps the command from being reported as failed,
+			// the trigger ID stays valid either way
+			b.Ack(*evt.Request)
+
+			t := &ModalTrigger{User: cmd.UserID, Channel: cmd.ChannelID, TriggerID: cmd.TriggerID, Text: cmd.Text, ctx: ctx}
+			if err := b.openModal(cfg, t); err != nil {
+				b.log().Error("failed opening modal", "command", cmd.Command, "channel", cmd.ChannelID, "user", cmd.UserID, "error", err)
 			}
-			client.Ack(*evt.Request)
-		}
-	})
+		})
+	}
+
+	if cfg.Shortcut != "" {
+		b.handleCallback(slack.InteractionTypeShortcut, cfg.Shortcut, func(ctx context.Context, cb slack.InteractionCallback, evt *socketmode.Event, client *socketmode.Client) error {
+			return b.openModal(cfg, &ModalTrigger{User: cb.User.ID, TriggerID: cb.TriggerID, ctx: ctx})
+		}, false)
+	}
+
+	if cfg.MessageShortcut != "" {
+		b.handleCallback(slack.InteractionTypeMessageAction, cfg.MessageShortcut, func(ctx context.Context, cb slack.InteractionCallback, evt *socketmode.Event, client *socketmode.Client) error {
+			msg := cb.Message
+			return b.openModal(cfg, &ModalTrigger{User: cb.User.ID, Channel: cb.Channel.ID, TriggerID: cb.TriggerID, Message: &msg, ctx: ctx})
+		}, false)
+	}
+}
+
+// openModal opens the modal built by the config's View for the trigger
+func (b *Bot) openModal(cfg DialogCfg, t *ModalTrigger) error {
+	view, err := cfg.View(t)
+	if err != nil {
+		return err
+	}
+	if view.CallbackID == "" && cfg.CallbackId != "" {
+		view.CallbackID = t.User + cfg.CallbackId
+	}
+
+	_, err = b.OpenView(t.ctx, t.TriggerID, view)
+	return err
+}
+
+// registerDialog routes the interactions of a dialog or modal, submissions
+// and closing are of the given types
+func (b *Bot) registerDialog(evtHandlerCfg DialogCfg, submission, closed slack.InteractionType) {
+	if evtHandlerCfg.Dialog != nil {
+		b.handleCallback(slack.InteractionTypeInteractionMessage, b.ID+evtHandlerCfg.CallbackId, InteractionHandler(evtHandlerCfg.Dialog).withoutContext(b), false)
+	}
+	if evtHandlerCfg.SubmissionHandler != nil {
+		b.handleCallback(submission, evtHandlerCfg.CallbackId, InteractionHandler(evtHandlerCfg.SubmissionHandler).withoutContext(b), true)
+	}
+	if evtHandlerCfg.OnClose != nil {
+		b.handleCallback(closed, evtHandlerCfg.CallbackId, InteractionHandler(evtHandlerCfg.OnClose).withoutContext(b), true)
+	}
+}
+
+// interactionAttrs returns the log attributes describing a failed interaction
+func interactionAttrs(evt *socketmode.Event, callback slack.InteractionCallback, err error) []any {
+	callbackID := callback.CallbackID
+	if callbackID == "" {
+		callbackID = callback.View.CallbackID
+	}
+
+	return []any{
+		"event_type", evt.Type,
+		"interaction_type", callback.Type,
+		"callback_id", callbackID,
+		"channel", callback.Channel.ID,
+		"user", callback.User.ID,
+		"error", err,
+	}
 }
@@ -1,22 +1,492 @@
-package hanu
-
-//var Start time.Time
-//
-//func TestBot(t *testing.T) {
-//	bot, err := NewDebug(os.Getenv("SLACKTOKEN"), os.Getenv("SLACKAPPTOKEN"))
-//	if err != nil {
-//		log.Fatal(err)
-//	}
-//
-//	bot.Register(NewCommand("uptime",
-//		"Reply with the uptime",
-//		func(conv Convo) {
-//			conv.Reply("Thanks for asking! I'm running since `%s`", time.Since(Start))
-//		}))
-//
-//	ctx, cancel := context.WithCancel(context.Background())
-//
-//	defer cancel() //cancel when we are finished being a bot
-//
-//	bot.Listen(ctx)
-//}
+package hanu_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ChrisMcKee/hanu"
+	"github.com/ChrisMcKee/hanu/hanutest"
+)
+
+func TestBotReplyToDirectMessage(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.Command("whisper <word>", func(conv hanu.Convo) {
+		str, _ := conv.String("word")
+		conv.Reply(strings.ToLower(str))
+	})
+
+	h.Message("U1", "D1", "whisper TURTLES")
+
+	post := h.NextPost()
+	if post.Channel != "D1" {
+		t.Errorf("reply should be posted to D1, was posted to %s", post.Channel)
+	}
+
+	if post.Text != "turtles" {
+		t.Errorf("reply should be \"turtles\", is \"%s\"", post.Text)
+	}
+}
+
+func TestBotReplyToMention(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.Register(hanu.NewCommand("uptime", "Reply with the uptime", func(conv hanu.Convo) {
+		conv.Reply("running")
+	}))
+
+	h.Mention("U1", "C1", "uptime")
+
+	post := h.NextPost()
+	if post.Text != "<@U1>: running" {
+		t.Errorf("reply should mention the user, is \"%s\"", post.Text)
+	}
+}
+
+func TestBotCommandPrefix(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.SetCommandPrefix("!")
+	h.Bot.Command("ping", func(conv hanu.Convo) {
+		conv.Reply("pong")
+	})
+
+	h.Message("U1", "D1", "ping")
+	h.NoPost(100 * time.Millisecond)
+
+	h.Message("U1", "D1", "!ping")
+	if post := h.NextPost(); post.Text != "pong" {
+		t.Errorf("reply should be \"pong\", is \"%s\"", post.Text)
+	}
+}
+
+func TestBotHelp(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.Register(hanu.NewCommand("uptime", "Reply with the uptime", func(conv hanu.Convo) {}))
+	h.Bot.Command("ping", func(conv hanu.Convo) {})
+
+	h.Mention("U1", "C1", "help")
+
+	post := h.NextPost()
+	if !strings.HasPrefix(post.Text, "<@U1>: The available commands are:") {
+		t.Errorf("help should mention the user, is \"%s\"", post.Text)
+	}
+
+	if post.Text != "<@U1>: "+h.Bot.BuildHelpText() {
+		t.Errorf("help should list the commands, is \"%s\"", post.Text)
+	}
+
+	if !strings.Contains(post.Text, "`uptime` *–* Reply with the uptime") {
+		t.Errorf("help should describe uptime, is \"%s\"", post.Text)
+	}
+}
+
+func TestBotHelpRequiresMention(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.Command("ping", func(conv hanu.Convo) {})
+
+	h.Message("U1", "C1", "help")
+	h.NoPost(100 * time.Millisecond)
+}
+
+func TestBotReplyOnly(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.SetReplyOnly(true)
+	h.Bot.Command("ping", func(conv hanu.Convo) {
+		conv.Reply("pong")
+	})
+
+	h.Message("U1", "C1", "ping")
+	h.NoPost(100 * time.Millisecond)
+
+	h.Mention("U1", "C1", "ping")
+	if post := h.NextPost(); post.Text != "<@U1>: pong" {
+		t.Errorf("reply should be \"<@U1>: pong\", is \"%s\"", post.Text)
+	}
+}
+
+func TestBotUnknownCommand(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.SetReplyOnly(true).UnknownCommand(func(conv hanu.Convo) {
+		conv.Reply("unknown: %s", conv.Message().Text())
+	})
+	h.Bot.Command("ping", func(conv hanu.Convo) {})
+
+	h.Message("U1", "D1", "pong")
+
+	if post := h.NextPost(); post.Text != "unknown: pong" {
+		t.Errorf("unknown command handler should reply, is \"%s\"", post.Text)
+	}
+}
+
+func TestBotIgnoresUnmatched(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.UnknownCommand(func(conv hanu.Convo) {
+		conv.Reply("unknown")
+	})
+	h.Bot.Command("ping", func(conv hanu.Convo) {})
+
+	h.Message("U1", "C1", "hello everyone")
+	h.NoPost(100 * time.Millisecond)
+}
+
+func TestBotSay(t *testing.T) {
+	h := hanutest.New(t)
+
+	devops := h.Bot.Channel("C2")
+	devops.Say("Host called %s is not responding to pings", "bobsburgers01")
+
+	post := h.NextPost()
+	if post.Channel != "C2" || post.Text != "Host called bobsburgers01 is not responding to pings" {
+		t.Errorf("unexpected post to %s: \"%s\"", post.Channel, post.Text)
+	}
+}
+
+func TestBotThreadReplies(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.Command("ping", func(conv hanu.Convo) {
+		conv.Reply("pong")
+	})
+
+	h.ThreadMessage("U1", "C1", "1.1", "ping")
+	if post := h.NextPost(); post.ThreadTimestamp != "" {
+		t.Errorf("reply should not be threaded by default, is in %s", post.ThreadTimestamp)
+	}
+
+	h.Bot.SetThreadReplies(true)
+
+	h.ThreadMessage("U1", "C1", "1.1", "ping")
+	if post := h.NextPost(); post.ThreadTimestamp != "1.1" || post.Text != "pong" {
+		t.Errorf("reply should be \"pong\" in thread 1.1, is \"%s\" in %s", post.Text, post.ThreadTimestamp)
+	}
+
+	h.Message("U1", "C1", "ping")
+	if post := h.NextPost(); post.ThreadTimestamp != "" {
+		t.Errorf("reply to a top-level message should not be threaded, is in %s", post.ThreadTimestamp)
+	}
+}
+
+func TestBotReplyInThread(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.Command("deploy", func(conv hanu.Convo) {
+		conv.ReplyInThread("deploying")
+	})
+
+	ts := h.Message("U1", "C1", "deploy")
+
+	post := h.NextPost()
+	if post.Channel != "C1" || post.ThreadTimestamp != ts {
+		t.Errorf("reply should start a thread on %s, is in %s", ts, post.ThreadTimestamp)
+	}
+}
+
+func TestBotAsk(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.Command("deploy", func(conv hanu.Convo) {
+		env, err := conv.Ask(context.Background(), "Which env?")
+		if err != nil {
+			conv.Reply("error: %s", err)
+			return
+		}
+		conv.Reply("deploying to %s", env)
+	})
+	h.Bot.Command("prod", func(conv hanu.Convo) {
+		conv.Reply("prod is not a command")
+	})
+
+	h.Message("U1", "C1", "deploy")
+	if post := h.NextPost(); post.Text != "<@U1>: Which env?" {
+		t.Errorf("bot should ask for the env, said \"%s\"", post.Text)
+	}
+
+	h.Message("U2", "C1", "prod")
+	if post := h.NextPost(); post.Text != "<@U2>: prod is not a command" {
+		t.Errorf("other users should still run commands, got \"%s\"", post.Text)
+	}
+
+	h.Message("U1", "C1", "prod")
+	if post := h.NextPost(); post.Text != "<@U1>: deploying to prod" {
+		t.Errorf("answer should reach the handler, got \"%s\"", post.Text)
+	}
+}
+
+func TestBotAskInThread(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.SetThreadReplies(true)
+	h.Bot.Command("deploy", func(conv hanu.Convo) {
+		env, _ := conv.Ask(context.Background(), "Which env?")
+		conv.Reply("deploying to %s", env)
+	})
+
+	h.ThreadMessage("U1", "C1", "1.1", "deploy")
+	if post := h.NextPost(); post.ThreadTimestamp != "1.1" {
+		t.Errorf("question should be asked in thread 1.1, was asked in %s", post.ThreadTimestamp)
+	}
+
+	h.Message("U1", "C1", "prod")
+	h.ThreadMessage("U1", "C1", "1.1", "test")
+	if post := h.NextPost(); post.Text != "deploying to test" {
+		t.Errorf("answer should come from the thread, got \"%s\"", post.Text)
+	}
+}
+
+func TestBotAskInThreadWithoutThreadReplies(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.Command("deploy", func(conv hanu.Convo) {
+		env, _ := conv.Ask(context.Background(), "Which env?")
+		conv.Reply("deploying to %s", env)
+	})
+
+	h.ThreadMessage("U1", "C1", "1.1", "deploy")
+	if post := h.NextPost(); post.ThreadTimestamp != "1.1" {
+		t.Errorf("question should be asked in thread 1.1 where the answer is expected, was asked in \"%s\"", post.ThreadTimestamp)
+	}
+
+	h.ThreadMessage("U1", "C1", "1.1", "prod")
+	if post := h.NextPost(); post.Text != "<@U1>: deploying to prod" {
+		t.Errorf("answer in the thread should reach the handler, got \"%s\"", post.Text)
+	}
+}
+
+func TestBotAskTimeout(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.Command("deploy", func(conv hanu.Convo) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		if _, err := conv.Ask(ctx, "Which env?"); err != nil {
+			conv.Reply("%s", err)
+		}
+	})
+
+	h.Message("U1", "D1", "deploy")
+	h.NextPost()
+
+	if post := h.NextPost(); post.Text != context.DeadlineExceeded.Error() {
+		t.Errorf("question should time out, got \"%s\"", post.Text)
+	}
+}
+
+func TestBotPermissions(t *testing.T) {
+	h := hanutest.New(t)
+	h.Server.Handle("usergroups.users.list", func(call hanutest.Call) interface{} {
+		return map[string]interface{}{"ok": true, "users": []string{"UOPS"}}
+	})
+
+	deploy := hanu.NewCommand("deploy", "Deploy a service", func(conv hanu.Convo) {
+		conv.Reply("deploying")
+	})
+	deploy.SetPermissions(hanu.Permissions{UserGroups: []string{"SOPS"}})
+	h.Bot.Register(deploy)
+	h.Bot.Command("ping", func(conv hanu.Convo) {})
+
+	h.Message("U1", "D1", "deploy")
+	if post := h.NextPost(); post.Text != "Sorry, you are not allowed to run this command." {
+		t.Errorf("bot should deny the command, got \"%s\"", post.Text)
+	}
+
+	h.Message("UOPS", "D1", "deploy")
+	if post := h.NextPost(); post.Text != "deploying" {
+		t.Errorf("bot should run the command for group members, got \"%s\"", post.Text)
+	}
+
+	if calls := h.Server.Calls("usergroups.users.list"); len(calls) == 0 || calls[0].Values.Get("usergroup") != "SOPS" {
+		t.Errorf("bot should look up the members of SOPS, calls: %+v", calls)
+	}
+}
+
+func TestBotPermissionsRememberGroupMembers(t *testing.T) {
+	h := hanutest.New(t)
+	h.Server.Handle("usergroups.users.list", func(call hanutest.Call) interface{} {
+		return map[string]interface{}{"ok": true, "users": []string{"UOPS"}}
+	})
+
+	deploy := hanu.NewCommand("deploy", "Deploy a service", func(conv hanu.Convo) {
+		conv.Reply("deploying")
+	})
+	deploy.SetPermissions(hanu.Permissions{UserGroups: []string{"SOPS"}})
+	h.Bot.Register(deploy)
+
+	h.Message("UOPS", "D1", "deploy")
+	h.NextPost()
+	h.Message("UOPS", "D1", "deploy")
+	h.NextPost()
+	h.Mention("UOPS", "C1", "help")
+	h.NextPost()
+
+	if calls := h.Server.Calls("usergroups.users.list"); len(calls) != 1 {
+		t.Errorf("members of SOPS should be listed once, were listed %d times", len(calls))
+	}
+}
+
+func TestBotPermissionLookupFailure(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.SetErrorReply("Something went wrong")
+	h.Server.Handle("usergroups.users.list", func(call hanutest.Call) interface{} {
+		return map[string]interface{}{"ok": false, "error": "ratelimited"}
+	})
+
+	deploy := hanu.NewCommand("deploy", "Deploy a service", func(conv hanu.Convo) {
+		conv.Reply("deploying")
+	})
+	deploy.SetPermissions(hanu.Permissions{UserGroups: []string{"SOPS"}})
+	h.Bot.Register(deploy)
+
+	h.Message("UOPS", "D1", "deploy")
+	if post := h.NextPost(); post.Text != "Something went wrong" {
+		t.Errorf("failing to look up the group should be reported as an error, got \"%s\"", post.Text)
+	}
+}
+
+func TestBotHelpHidesRestrictedCommands(t *testing.T) {
+	h := hanutest.New(t)
+
+	deploy := hanu.NewCommand("deploy", "Deploy a service", func(conv hanu.Convo) {})
+	deploy.SetPermissions(hanu.Permissions{Users: []string{"UOPS"}})
+	h.Bot.Register(deploy)
+	h.Bot.Register(hanu.NewCommand("ping", "Ping the bot", func(conv hanu.Convo) {}))
+
+	h.Message("U1", "D1", "help")
+	if post := h.NextPost(); strings.Contains(post.Text, "deploy") || !strings.Contains(post.Text, "ping") {
+		t.Errorf("help should only list ping, got \"%s\"", post.Text)
+	}
+
+	h.Message("UOPS", "D1", "help")
+	if post := h.NextPost(); !strings.Contains(post.Text, "deploy") {
+		t.Errorf("help should list deploy for UOPS, got \"%s\"", post.Text)
+	}
+
+	if !strings.Contains(h.Bot.BuildHelpText(), "deploy") {
+		t.Errorf("BuildHelpText should list all commands")
+	}
+}
+
+func TestBotSilentDenial(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.SetDenyReply("")
+
+	deploy := hanu.NewCommand("deploy", "", func(conv hanu.Convo) {
+		conv.Reply("deploying")
+	})
+	deploy.SetPermissions(hanu.Permissions{Channels: []string{"COPS"}})
+	h.Bot.Register(deploy)
+
+	h.Message("U1", "C1", "deploy")
+	h.NoPost(100 * time.Millisecond)
+}
+
+func TestBotUserInGroup(t *testing.T) {
+	h := hanutest.New(t)
+	h.Server.Handle("usergroups.users.list", func(call hanutest.Call) interface{} {
+		return map[string]interface{}{"ok": true, "users": []string{"UOPS"}}
+	})
+
+	if ok, err := h.Bot.UserInGroup("UOPS", "SOPS"); !ok || err != nil {
+		t.Errorf("UOPS should be in SOPS, got %v (%v)", ok, err)
+	}
+
+	if ok, _ := h.Bot.UserInGroup("U1", "SOPS"); ok {
+		t.Errorf("U1 should not be in SOPS")
+	}
+
+	if !h.Bot.IsUserInGroup("UOPS", "SOPS", nil) {
+		t.Errorf("deprecated IsUserInGroup should still check membership")
+	}
+}
+
+func TestBotCommandHelp(t *testing.T) {
+	h := hanutest.New(t)
+	deploy := hanu.NewCommand("deploy <service>", "Deploy a service", func(conv hanu.Convo) {})
+	deploy.SetParameterDescription("service", "name of the service")
+	deploy.SetExamples("deploy api")
+	h.Bot.Register(deploy)
+	h.Bot.Register(hanu.NewCommand("deploy list", "List deployments", func(conv hanu.Convo) {}))
+	h.Bot.Register(hanu.NewCommand("ping", "Ping the bot", func(conv hanu.Convo) {}))
+
+	h.Message("U1", "D1", "help deploy")
+
+	post := h.NextPost()
+	if !strings.Contains(post.Text, "`service` – name of the service") || !strings.Contains(post.Text, "`deploy api`") {
+		t.Errorf("help should describe deploy in detail, got \"%s\"", post.Text)
+	}
+
+	if !strings.Contains(post.Text, "`deploy list`") || strings.Contains(post.Text, "ping") {
+		t.Errorf("help should only list the deploy commands, got \"%s\"", post.Text)
+	}
+
+	h.Message("U1", "D1", "help nothing")
+	if post := h.NextPost(); !strings.HasPrefix(post.Text, "The available commands are:") {
+		t.Errorf("help for an unknown command should list all commands, got \"%s\"", post.Text)
+	}
+}
+
+func TestBotUsageReply(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.Register(hanu.NewCommand("deploy <service> <staging|prod>", "Deploy a service", func(conv hanu.Convo) {
+		conv.Reply("deploying")
+	}))
+
+	h.Message("U1", "D1", "deploy api")
+	if post := h.NextPost(); !strings.HasPrefix(post.Text, "Usage:\n`deploy <service> <staging|prod>`") {
+		t.Errorf("missing parameter should be answered with the usage, got \"%s\"", post.Text)
+	}
+
+	h.Message("U1", "D1", "deploy api dev")
+	if post := h.NextPost(); !strings.HasPrefix(post.Text, "`dev` is not one of `staging|prod`\nUsage:") {
+		t.Errorf("invalid option should be answered with the usage, got \"%s\"", post.Text)
+	}
+
+	h.Message("U1", "D1", "deploy api prod")
+	if post := h.NextPost(); post.Text != "deploying" {
+		t.Errorf("valid request should run the command, got \"%s\"", post.Text)
+	}
+
+	h.Message("U1", "C1", "deploy is broken again")
+	h.NoPost(100 * time.Millisecond)
+}
+
+func TestBotInvalidOptionTriesOtherCommands(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.Command("deploy <staging|prod>", func(conv hanu.Convo) {
+		conv.Reply("deploying everything")
+	})
+	h.Bot.Command("deploy <service>", func(conv hanu.Convo) {
+		service, _ := conv.String("service")
+		conv.Reply("deploying %s", service)
+	})
+
+	h.Message("U1", "D1", "deploy api")
+	if post := h.NextPost(); post.Text != "deploying api" {
+		t.Errorf("later matching command should run, got \"%s\"", post.Text)
+	}
+	h.NoPost(100 * time.Millisecond)
+}
+
+func TestBotDidYouMean(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.Register(hanu.NewCommand("deploy <service>", "Deploy a service", func(conv hanu.Convo) {}))
+	h.Bot.Register(hanu.NewCommand("ping", "Ping the bot", func(conv hanu.Convo) {}))
+
+	h.Mention("U1", "C1", "delpoy api")
+	if post := h.NextPost(); post.Text != "<@U1>: Did you mean:\n`deploy <service>` *–* Deploy a service\n" {
+		t.Errorf("typo should be answered with a suggestion, got \"%s\"", post.Text)
+	}
+
+	h.Mention("U1", "C1", "something else")
+	h.NoPost(100 * time.Millisecond)
+}
+
+func TestBotCommandTimeout(t *testing.T) {
+	h := hanutest.New(t)
+
+	slow := hanu.NewCommand("slow", "", func(conv hanu.Convo) {
+		<-conv.Context().Done()
+		conv.Reply(conv.Context().Err().Error())
+	})
+	slow.SetTimeout(50 * time.Millisecond)
+	h.Bot.Register(slow)
+
+	h.Message("U1", "D1", "slow")
+	if post := h.NextPost(); post.Text != context.DeadlineExceeded.Error() {
+		t.Errorf("the context should time out, reply is \"%s\"", post.Text)
+	}
+}
@@ -29,14 +29,29 @@ func (b *Bot) GetUserEmail(conv Convo) (string, error) {
 	return user.Profile.Email, nil
 }
 
-func (b *Bot) IsUserInGroup(email string, userGroup string, conv Convo) bool {
+// UserInGroup checks if the user with the given ID is a member of the user group
+func (b *Bot) UserInGroup(userID string, userGroup string) (bool, error) {
 	api := b.SocketClient.Client
 
-	userGroups, err := api.GetUserGroupMembers(userGroup)
+	members, err := api.GetUserGroupMembers(userGroup)
 	if err != nil {
-		api.Debugf("Unexpected error: %s", err)
+		return false, err
+	}
+
+	return contains(members, userID), nil
+}
+
+// IsUserInGroup checks if the user with the given ID is a member of the user
+// group, errors are logged and reported as not being a member.
+//
+// Deprecated: user groups list user IDs, not emails. Use UserInGroup, or
+// Permissions to restrict commands.
+func (b *Bot) IsUserInGroup(userID string, userGroup string, conv Convo) bool {
+	ok, err := b.UserInGroup(userID, userGroup)
+	if err != nil {
+		b.log().Debug("failed listing user group members", "user_group", userGroup, "error", err)
 		return false
 	}
 
-	return contains(userGroups, email)
+	return ok
 }
@@ -8,6 +8,16 @@ type Channel struct {
 }
 
 // Say will cause the bot to say something in the channel
-func (ch *Channel) Say(msg string, a ...interface{}) {
-	ch.bot.Say(ch.ID, msg, a...)
+func (ch *Channel) Say(msg string, a ...interface{}) *SentMessage {
+	return ch.bot.Say(ch.ID, msg, a...)
+}
+
+// SayInThread will cause the bot to say something in a thread of the channel
+func (ch *Channel) SayInThread(threadTS, msg string, a ...interface{}) *SentMessage {
+	return ch.bot.SayInThread(ch.ID, threadTS, msg, a...)
+}
+
+// SayBlocks will cause the bot to post a Block Kit message in the channel
+func (ch *Channel) SayBlocks(blocks *Blocks) *SentMessage {
+	return ch.bot.SayBlocks(ch.ID, blocks)
 }
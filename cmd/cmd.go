@@ -4,6 +4,7 @@ import "github.com/ChrisMcKee/hanu"
 
 var commandList []hanu.CommandInterface
 var dialogInteractions []hanu.DialogCfg
+var actions = make(map[string]hanu.ActionHandler)
 
 var Bot *hanu.Bot
 
@@ -16,6 +17,11 @@ func RegisterDialogInteraction(cfg hanu.DialogCfg) {
 	dialogInteractions = append(dialogInteractions, cfg)
 }
 
+// RegisterAction adds the handler of block actions with the given action ID
+func RegisterAction(actionID string, h hanu.ActionHandler) {
+	actions[actionID] = h
+}
+
 // List returns commandList
 func List() []hanu.CommandInterface {
 	return commandList
@@ -24,3 +30,8 @@ func List() []hanu.CommandInterface {
 func ListDialogInteractions() []hanu.DialogCfg {
 	return dialogInteractions
 }
+
+// ListActions returns the action handlers by action ID
+func ListActions() map[string]hanu.ActionHandler {
+	return actions
+}
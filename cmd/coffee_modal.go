@@ -1,8 +1,8 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/ChrisMcKee/hanu"
@@ -14,75 +14,49 @@ func init() {
 	Register("coffee-modal",
 		"Reply with the coffee action modal",
 		func(conv hanu.Convo) {
-			attachment := slack.Attachment{
-				Text:       "I am Coffeebot :robot_face:, and I'm here to help bring you fresh coffee :coffee:",
-				Color:      "#3AA3E3",
-				CallbackID: Bot.ID + "modal_coffee_order_form",
-				Actions: []slack.AttachmentAction{
-					{
-						Name:  "coffee_order",
-						Text:  ":coffee: Order Coffee",
-						Type:  "button",
-						Value: "coffee_order",
-					},
-				},
-			}
+			blocks := hanu.NewBlocks().
+				Text("Coffeebot can bring you fresh coffee").
+				Section("I am Coffeebot :robot_face:, and I'm here to help bring you fresh coffee :coffee:").
+				Buttons(hanu.Button{ActionID: "modal_coffee_order", Text: ":coffee: Order Coffee", Value: "coffee_order"})
 
-			options := slack.MsgOptionAttachments(attachment)
-			if _, _, err := Bot.SocketClient.Client.PostMessage(conv.Message().Channel(), options); err != nil {
-				fmt.Printf("failed to post message: %s", err)
+			if err := conv.ReplyBlocks(blocks).Wait(); err != nil {
+				Bot.Logger().Error("failed to post message", "error", err)
 			}
 		})
 
+	RegisterAction("modal_coffee_order", func(a *hanu.Action) error {
+		if _, err := Bot.OpenView(a.Context(), a.TriggerID, makeCoffeeModal(a.User)); err != nil {
+			return fmt.Errorf("open modal failed: %w", err)
+		}
+
+		return a.UpdateMessageBlocks(hanu.NewBlocks().Section(":pencil: Taking your order..."))
+	})
+
 	RegisterDialogInteraction(hanu.DialogCfg{
-		Type:       hanu.Modal,
-		CallbackId: "modal_coffee_order_form",
-		Dialog: func(b *hanu.Bot, cb slack.InteractionCallback, evt *socketmode.Event, client *socketmode.Client) error {
-			msg, done := coffeeModalRequest(cb, client)
-			if done {
-				client.Ack(*evt.Request, msg)
-				return nil
-			}
-			return nil
+		Type:         hanu.Modal,
+		CallbackId:   "modal_coffee_order_form",
+		SlashCommand: "/coffee",
+		View: func(t *hanu.ModalTrigger) (slack.ModalViewRequest, error) {
+			return makeCoffeeModal(t.User), nil
 		},
 		SubmissionHandler: func(b *hanu.Bot, cb slack.InteractionCallback, evt *socketmode.Event, client *socketmode.Client) error {
-			client.Debugf("Order received: %+v\n", cb.Submission)
+			b.Logger().Debug("order received", "user", cb.User.ID, "submission", cb.View.State)
 
-			updateModal := updateCoffeeModal()
-			_, err := client.UpdateView(updateModal, "", cb.View.Hash, cb.View.ID)
-			if err != nil {
-				log.Printf("Error updating view: %s", err)
-				return nil
+			if _, err := b.UpdateView(context.Background(), updateCoffeeModal(), cb.View.ID, cb.View.Hash); err != nil {
+				return fmt.Errorf("updating view failed: %w", err)
 			}
 			time.Sleep(time.Second * 2)
 
-			client.Ack(*evt.Request)
+			b.Ack(*evt.Request)
+			return nil
+		},
+		OnClose: func(b *hanu.Bot, cb slack.InteractionCallback, evt *socketmode.Event, client *socketmode.Client) error {
+			b.Logger().Debug("order cancelled", "user", cb.User.ID)
 			return nil
 		},
 	})
 }
 
-// Services the command
-var coffeeModalOrders = make(map[string]map[string]string)
-
-func coffeeModalRequest(message slack.InteractionCallback, client *socketmode.Client) (slack.Message, bool) {
-	if _, ok := coffeeModalOrders[message.User.ID]["MessageTs"]; !ok {
-		coffeeModalOrders[message.User.ID] = make(map[string]string)
-	}
-	coffeeModalOrders[message.User.ID]["MessageTs"] = message.MessageTs
-	coffeeModal := makeCoffeeModal(message.User.ID)
-	if _, err := client.OpenView(message.TriggerID, coffeeModal); err != nil {
-		log.Print("open modal failed: ", err)
-		return slack.Message{}, true
-	}
-	msg := message.OriginalMessage
-	msg.ReplaceOriginal = true
-	msg.Timestamp = coffeeModalOrders[message.User.ID]["order_channel"]
-	msg.Text = ":pencil: Taking your order..."
-	msg.Attachments = []slack.Attachment{}
-	return msg, false
-}
-
 func makeCoffeeModal(userID string) slack.ModalViewRequest {
 	// Create a ModalViewRequest with a header and two inputs
 	titleText := slack.NewTextBlockObject("plain_text", "Coffee Modal", false, false)
@@ -116,6 +90,7 @@ func makeCoffeeModal(userID string) slack.ModalViewRequest {
 	modalRequest.Submit = submitText
 	modalRequest.Blocks = blocks
 	modalRequest.CallbackID = userID + "modal_coffee_order_form"
+	modalRequest.NotifyOnClose = true
 	return modalRequest
 }
 
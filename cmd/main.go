@@ -58,6 +58,10 @@ func main() {
 			}
 		}
 
+		for actionID, h := range ListActions() {
+			bot.OnAction(actionID, h)
+		}
+
 		bot.Listen(ctx)
 	}()
 
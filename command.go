@@ -1,12 +1,18 @@
 package hanu
 
 import (
+	"context"
+	"time"
+
 	"github.com/ChrisMcKee/allot"
 )
 
 // Handler is the interface for the handler function
 type Handler func(Convo)
 
+// HandlerE is the interface for a handler function that can fail
+type HandlerE func(Convo) error
+
 // CommandInterface defines a command interface
 type CommandInterface interface {
 	Get() allot.CommandInterface
@@ -18,11 +24,23 @@ type CommandInterface interface {
 type Command struct {
 	command     allot.CommandInterface
 	description string
-	handler     Handler
+	handler     HandlerE
+	permissions Permissions
+	parameters  map[string]string
+	examples    []string
+	timeout     time.Duration
 }
 
 // SetHandler sets the handler
 func (c *Command) SetHandler(handler Handler) {
+	c.handler = func(conv Convo) error {
+		handler(conv)
+		return nil
+	}
+}
+
+// SetHandlerE sets a handler that can fail
+func (c *Command) SetHandlerE(handler HandlerE) {
 	c.handler = handler
 }
 
@@ -36,9 +54,72 @@ func (c *Command) SetDescription(text string) {
 	c.description = text
 }
 
-// Handle calls the command's handler
+// ParameterDescription returns the description of a parameter
+func (c Command) ParameterDescription(name string) string {
+	return c.parameters[name]
+}
+
+// SetParameterDescription describes a parameter for the command's help
+func (c *Command) SetParameterDescription(name string, text string) {
+	if c.parameters == nil {
+		c.parameters = make(map[string]string)
+	}
+	c.parameters[name] = text
+}
+
+// Examples returns the example requests
+func (c Command) Examples() []string {
+	return c.examples
+}
+
+// SetExamples sets example requests for the command's help
+func (c *Command) SetExamples(examples ...string) {
+	c.examples = examples
+}
+
+// Permissions returns who can run the command and where
+func (c Command) Permissions() Permissions {
+	return c.permissions
+}
+
+// SetPermissions restricts who can run the command and where
+func (c *Command) SetPermissions(p Permissions) {
+	c.permissions = p
+}
+
+// Timeout returns how long the command may run
+func (c Command) Timeout() time.Duration {
+	return c.timeout
+}
+
+// SetTimeout limits how long the command may run, the conversation's context
+// is cancelled once it passed. Zero means no limit.
+func (c *Command) SetTimeout(d time.Duration) {
+	c.timeout = d
+}
+
+// Handle calls the command's handler, use HandleE to get its error
 func (c Command) Handle(conv ConversationInterface) {
-	go c.handler(conv)
+	c.HandleE(conv)
+}
+
+// HandleE calls the command's handler and returns its error, the bot
+// reports errors of commands implementing HandleE
+func (c Command) HandleE(conv ConversationInterface) error {
+	return c.handler(conv)
+}
+
+// commandContext derives the context of a command's conversation, applying
+// the timeout of commands implementing Timeout
+func commandContext(ctx context.Context, cmd CommandInterface) (context.Context, context.CancelFunc) {
+	c, ok := cmd.(interface {
+		Timeout() time.Duration
+	})
+	if !ok || c.Timeout() <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, c.Timeout())
 }
 
 // Get returns the command
@@ -60,3 +141,13 @@ func NewCommand(text string, description string, handler Handler) Command {
 
 	return cmd
 }
+
+// NewCommandE creates a new command with a handler that can fail
+func NewCommandE(text string, description string, handler HandlerE) Command {
+	cmd := Command{}
+	cmd.Set(allot.New(text))
+	cmd.SetDescription(description)
+	cmd.SetHandlerE(handler)
+
+	return cmd
+}
@@ -0,0 +1,109 @@
+package hanu
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// commandRouter indexes commands by their literal leading words, so only the
+// commands a message could match are run through allot's regex matching.
+// With a command prefix the prefix is part of the first literal word.
+// Commands changed in place rather than registered keep the stale index
+// until the next registration.
+type commandRouter struct {
+	mu       sync.Mutex
+	commands []CommandInterface
+	root     *routeNode
+	// registered counts the registrations, the index is rebuilt when it
+	// changed since the index was built
+	registered uint64
+	built      uint64
+}
+
+// routeNode holds the positions of the commands whose literal words lead to it
+type routeNode struct {
+	children map[string]*routeNode
+	commands []int
+}
+
+// candidates returns the commands whose literal words the text starts with,
+// in registration order
+func (r *commandRouter) candidates(commands []CommandInterface, text string) []CommandInterface {
+	root := r.index(commands)
+
+	var positions []int
+	node := root
+	positions = append(positions, node.commands...)
+	for _, field := range strings.Fields(text) {
+		if node = node.children[field]; node == nil {
+			break
+		}
+		positions = append(positions, node.commands...)
+	}
+
+	sort.Ints(positions)
+
+	cmds := make([]CommandInterface, 0, len(positions))
+	for _, i := range positions {
+		cmds = append(cmds, commands[i])
+	}
+
+	return cmds
+}
+
+// invalidate makes the next lookup rebuild the index
+func (r *commandRouter) invalidate() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.registered++
+}
+
+// index returns the index of the commands, it is rebuilt when commands were
+// registered or the slice was replaced since it was built
+func (r *commandRouter) index(commands []CommandInterface) *routeNode {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.root != nil && r.built == r.registered && sameCommands(r.commands, commands) {
+		return r.root
+	}
+
+	root := &routeNode{}
+	for i, cmd := range commands {
+		node := root
+		for _, word := range commandWords(cmd) {
+			// allot treats command texts as regular expressions, words
+			// that are not literal could match anything
+			if regexp.QuoteMeta(word) != word {
+				break
+			}
+
+			if node.children == nil {
+				node.children = make(map[string]*routeNode)
+			}
+			if node.children[word] == nil {
+				node.children[word] = &routeNode{}
+			}
+			node = node.children[word]
+		}
+		node.commands = append(node.commands, i)
+	}
+
+	r.commands = commands
+	r.root = root
+	r.built = r.registered
+
+	return root
+}
+
+// sameCommands checks if two slices share the same backing array and length
+func sameCommands(a, b []CommandInterface) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	return len(a) == 0 || &a[0] == &b[0]
+}
@@ -0,0 +1,138 @@
+package hanu
+
+import (
+	"fmt"
+	"testing"
+)
+
+func routerCommands(n int) []CommandInterface {
+	var cmds []CommandInterface
+	for i := 0; i < n; i++ {
+		cmds = append(cmds,
+			NewCommand(fmt.Sprintf("service%d list", i), "", func(conv Convo) {}),
+			NewCommand(fmt.Sprintf("service%d deploy <env>", i), "", func(conv Convo) {}),
+		)
+	}
+
+	return cmds
+}
+
+func TestRouterCandidates(t *testing.T) {
+	cmds := []CommandInterface{
+		NewCommand("deploy list", "", func(conv Convo) {}),
+		NewCommand("<anything>", "", func(conv Convo) {}),
+		NewCommand("deploy <service>", "", func(conv Convo) {}),
+		NewCommand("!ping", "", func(conv Convo) {}),
+		NewCommand("status.*", "", func(conv Convo) {}),
+	}
+
+	data := []struct {
+		text string
+		want []string
+	}{
+		{"deploy list", []string{"deploy list", "<anything>", "deploy <service>", "status.*"}},
+		{"deploy api", []string{"<anything>", "deploy <service>", "status.*"}},
+		{"!ping", []string{"<anything>", "!ping", "status.*"}},
+		{"ping", []string{"<anything>", "status.*"}},
+		{"", []string{"<anything>", "status.*"}},
+	}
+
+	var r commandRouter
+	for _, d := range data {
+		var got []string
+		for _, cmd := range r.candidates(cmds, d.text) {
+			got = append(got, cmd.Get().Text())
+		}
+
+		if fmt.Sprint(got) != fmt.Sprint(d.want) {
+			t.Errorf("candidates for \"%s\" should be %v, are %v", d.text, d.want, got)
+		}
+	}
+}
+
+func TestRouterReindexes(t *testing.T) {
+	var r commandRouter
+	cmds := make([]CommandInterface, 0, 2)
+	cmds = append(cmds, NewCommand("ping", "", func(conv Convo) {}))
+
+	if len(r.candidates(cmds, "pong")) != 0 {
+		t.Errorf("pong should not have candidates yet")
+	}
+
+	cmds = append(cmds, NewCommand("pong", "", func(conv Convo) {}))
+	if len(r.candidates(cmds, "pong")) != 1 {
+		t.Errorf("pong should be found after it was added")
+	}
+}
+
+func TestRouterReindexesOnRegistration(t *testing.T) {
+	var r commandRouter
+	cmds := []CommandInterface{NewCommand("ping", "", func(conv Convo) {})}
+
+	if len(r.candidates(cmds, "pong")) != 0 {
+		t.Errorf("pong should not have candidates yet")
+	}
+
+	cmds[0] = NewCommand("pong", "", func(conv Convo) {})
+	r.invalidate()
+	if len(r.candidates(cmds, "pong")) != 1 {
+		t.Errorf("pong should be found after it replaced ping")
+	}
+}
+
+func TestRouterParameterInWord(t *testing.T) {
+	var r commandRouter
+	cmds := []CommandInterface{NewCommand("deploy<service>", "", func(conv Convo) {})}
+
+	if len(r.candidates(cmds, "deployapi")) != 1 {
+		t.Errorf("commands with a parameter in their first word should be candidates")
+	}
+}
+
+// linearSearch is how commands were matched before they were indexed
+func linearSearch(cmds []CommandInterface, text string) CommandInterface {
+	for _, cmd := range cmds {
+		if _, err := cmd.Get().Match(text); err == nil && hasWordCount(cmd, text) {
+			return cmd
+		}
+	}
+
+	return nil
+}
+
+func indexedSearch(r *commandRouter, cmds []CommandInterface, text string) CommandInterface {
+	for _, cmd := range r.candidates(cmds, text) {
+		if !hasWordCount(cmd, text) {
+			continue
+		}
+		if _, err := cmd.Get().Match(text); err == nil {
+			return cmd
+		}
+	}
+
+	return nil
+}
+
+func BenchmarkLinearSearch(b *testing.B) {
+	cmds := routerCommands(250)
+
+	for i := 0; i < b.N; i++ {
+		linearSearch(cmds, "service200 deploy prod")
+		linearSearch(cmds, "just chatting in the channel")
+	}
+}
+
+func BenchmarkIndexedSearch(b *testing.B) {
+	cmds := routerCommands(250)
+	var r commandRouter
+
+	if indexedSearch(&r, cmds, "service200 deploy prod").Get().Text() != linearSearch(cmds, "service200 deploy prod").Get().Text() {
+		b.Fatal("indexed search should find the same command")
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		indexedSearch(&r, cmds, "service200 deploy prod")
+		indexedSearch(&r, cmds, "just chatting in the channel")
+	}
+}
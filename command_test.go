@@ -1,7 +1,10 @@
 package hanu
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 )
 
 func TestCommand(t *testing.T) {
@@ -39,7 +42,7 @@ func TestHandle(t *testing.T) {
 
 	match, _ := cmd.Get().Match(msg.Text())
 
-	conv := NewConversation(match, msg, nil)
+	conv := NewConversation(context.Background(), match, msg, nil)
 	cmd.Handle(conv)
 }
 
@@ -56,10 +59,50 @@ func TestHandleComplex(t *testing.T) {
 	)
 
 	msg := Message{}
-	msg.SetText("cmd name")
+	msg.SetText("cmd name prod env email")
 
 	match, _ := cmd.Get().Match(msg.Text())
 
-	conv := NewConversation(match, msg, nil)
+	conv := NewConversation(context.Background(), match, msg, nil)
 	cmd.Handle(conv)
 }
+
+func TestHandleE(t *testing.T) {
+	failure := errors.New("failure")
+
+	cmd := NewCommandE(
+		"cmd",
+		"Description",
+		func(conv Convo) error {
+			return failure
+		},
+	)
+
+	match, _ := cmd.Get().Match("cmd")
+
+	if err := cmd.HandleE(NewConversation(context.Background(), match, Message{}, nil)); err != failure {
+		t.Errorf("HandleE should return the handler's error, got %v", err)
+	}
+
+	plain := NewCommand("cmd", "Description", func(conv Convo) {})
+	if err := plain.HandleE(NewConversation(context.Background(), match, Message{}, nil)); err != nil {
+		t.Errorf("HandleE of a plain handler should not fail, got %v", err)
+	}
+}
+
+func TestCommandContext(t *testing.T) {
+	cmd := NewCommand("cmd", "Description", func(conv Convo) {})
+
+	ctx, cancel := commandContext(context.Background(), cmd)
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Errorf("commands without timeout should not have a deadline")
+	}
+
+	cmd.SetTimeout(time.Minute)
+	ctx, cancel = commandContext(context.Background(), cmd)
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > time.Minute {
+		t.Errorf("commands with timeout should have a deadline within a minute, got %v", deadline)
+	}
+}
@@ -1,9 +1,17 @@
 package hanu
 
 import (
+	"context"
+	"errors"
+	"io"
+
 	"github.com/ChrisMcKee/allot"
 )
 
+// ErrNoBot is returned when asking a question in a conversation that is not
+// attached to a listening bot
+var ErrNoBot = errors.New("conversation is not attached to a bot")
+
 // Convo is a shorthand for ConversationInterface
 type Convo ConversationInterface
 
@@ -11,14 +19,22 @@ type Convo ConversationInterface
 type ConversationInterface interface {
 	Integer(name string) (int, error)
 	String(name string) (string, error)
-	Reply(text string, a ...interface{})
+	Reply(text string, a ...interface{}) *SentMessage
+	ReplyInThread(text string, a ...interface{}) *SentMessage
+	ReplyBlocks(blocks *Blocks) *SentMessage
+	ReplyEphemeral(text string, a ...interface{}) error
+	ReplyFile(name string, r io.Reader) error
+	Download(file File, w io.Writer) error
+	Ask(ctx context.Context, text string, a ...interface{}) (string, error)
 	Match(position int) (string, error)
 	Message() MessageInterface
+	Context() context.Context
 }
 
 // Sayer is an object that can talk in the channel
 type Sayer interface {
-	Say(string, string, ...interface{})
+	Say(string, string, ...interface{}) *SentMessage
+	SayInThread(string, string, string, ...interface{}) *SentMessage
 }
 
 // Conversation stores message, command and socket information and is passed
@@ -27,6 +43,7 @@ type Conversation struct {
 	message Message
 	match   allot.MatchInterface
 	bot     Sayer
+	ctx     context.Context
 }
 
 // Message returns the convos message
@@ -34,15 +51,137 @@ func (c *Conversation) Message() MessageInterface {
 	return c.message
 }
 
-// Reply sends message using the socket to Slack
-func (c *Conversation) Reply(text string, a ...interface{}) {
+// Context returns the context of the event that started the conversation,
+// it is cancelled when the bot stops listening or shuts down, or when the
+// command's timeout passed
+func (c *Conversation) Context() context.Context {
+	return c.ctx
+}
+
+// Reply sends message using the socket to Slack, messages received in a
+// thread are answered in that thread if the bot is set to thread replies
+func (c *Conversation) Reply(text string, a ...interface{}) *SentMessage {
+	if c.message.IsThreaded() && c.threadReplies() {
+		return c.ReplyInThread(text, a...)
+	}
+
 	prefix := ""
 
 	if !c.message.IsDirectMessage() {
 		prefix = "<@" + c.message.User() + ">: "
 	}
 
-	c.bot.Say(c.Message().Channel(), prefix+text, a...)
+	return c.bot.Say(c.Message().Channel(), prefix+text, a...).WithContext(c.ctx)
+}
+
+// ReplyInThread answers in the thread of the message, starting a new
+// thread if the message was not posted in one
+func (c *Conversation) ReplyInThread(text string, a ...interface{}) *SentMessage {
+	ts := c.message.ThreadTimestamp()
+	if ts == "" {
+		ts = c.message.Timestamp()
+	}
+
+	return c.bot.SayInThread(c.Message().Channel(), ts, text, a...).WithContext(c.ctx)
+}
+
+// ReplyBlocks answers with a Block Kit message, in the message's thread if
+// Reply would answer there. Without a bot that can post blocks only the
+// fallback text is sent.
+func (c *Conversation) ReplyBlocks(blocks *Blocks) *SentMessage {
+	b, ok := c.bot.(interface {
+		SayBlocks(channel string, blocks *Blocks) *SentMessage
+		SayBlocksInThread(channel, threadTS string, blocks *Blocks) *SentMessage
+	})
+	if !ok {
+		return c.Reply("%s", blocks.FallbackText())
+	}
+
+	if c.message.IsThreaded() && c.threadReplies() {
+		return b.SayBlocksInThread(c.message.Channel(), c.message.ThreadTimestamp(), blocks).WithContext(c.ctx)
+	}
+
+	return b.SayBlocks(c.message.Channel(), blocks).WithContext(c.ctx)
+}
+
+// ReplyEphemeral answers with a message only the user who sent the message
+// can see, in the message's thread if Reply would answer there. If that is
+// not possible the answer is sent to the user directly.
+func (c *Conversation) ReplyEphemeral(text string, a ...interface{}) error {
+	b, ok := c.bot.(*Bot)
+	if !ok {
+		return ErrNoBot
+	}
+
+	ts := ""
+	if c.message.IsThreaded() && c.threadReplies() {
+		ts = c.message.ThreadTimestamp()
+	}
+
+	return b.sayEphemeralInThread(c.ctx, c.message.Channel(), ts, c.message.User(), text, a...)
+}
+
+// ReplyFile uploads the content of r as a file with the given name to the
+// message's channel, in the message's thread if Reply would answer there
+func (c *Conversation) ReplyFile(name string, r io.Reader) error {
+	b, ok := c.bot.(interface {
+		UploadFile(ctx context.Context, channel, threadTS, name string, r io.Reader) error
+	})
+	if !ok {
+		return ErrNoBot
+	}
+
+	ts := ""
+	if c.message.IsThreaded() && c.threadReplies() {
+		ts = c.message.ThreadTimestamp()
+	}
+
+	return b.UploadFile(c.ctx, c.message.Channel(), ts, name, r)
+}
+
+// Download writes the content of a file attached to the message to w
+func (c *Conversation) Download(file File, w io.Writer) error {
+	b, ok := c.bot.(interface {
+		DownloadFile(ctx context.Context, file File, w io.Writer) error
+	})
+	if !ok {
+		return ErrNoBot
+	}
+
+	return b.DownloadFile(c.ctx, file, w)
+}
+
+// Ask replies with a question and waits for the next message of the same
+// user in the same channel or thread, the answer is not treated as a command.
+// Questions to threaded messages are always asked in the thread, where the
+// answer is expected. It returns the context's error if no answer arrives
+// before it is done.
+func (c *Conversation) Ask(ctx context.Context, text string, a ...interface{}) (string, error) {
+	b, ok := c.bot.(*Bot)
+	if !ok {
+		return "", ErrNoBot
+	}
+
+	answers, cancel := b.prompts.wait(promptKey(c.message))
+	defer cancel()
+
+	if c.message.IsThreaded() {
+		c.ReplyInThread(text, a...)
+	} else {
+		c.Reply(text, a...)
+	}
+
+	select {
+	case msg := <-answers:
+		return msg.Text(), nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func (c *Conversation) threadReplies() bool {
+	b, ok := c.bot.(*Bot)
+	return ok && b.ThreadReplies
 }
 
 // String return string parameter
@@ -60,12 +199,18 @@ func (c Conversation) Match(position int) (string, error) {
 	return c.match.Match(position)
 }
 
-// NewConversation returns a Conversation struct
-func NewConversation(match allot.MatchInterface, msg Message, bot Sayer) ConversationInterface {
+// NewConversation returns a Conversation struct, a nil ctx is replaced with
+// context.Background()
+func NewConversation(ctx context.Context, match allot.MatchInterface, msg Message, bot Sayer) ConversationInterface {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
 	conv := &Conversation{
 		message: msg,
 		match:   match,
 		bot:     bot,
+		ctx:     ctx,
 	}
 
 	return conv
@@ -1,21 +1,29 @@
 package hanu
 
 import (
+	"context"
 	"testing"
 
 	"github.com/ChrisMcKee/allot"
 )
 
 type SayerMock struct {
-	msg  string
-	ch   string
-	args []interface{}
+	msg    string
+	ch     string
+	thread string
+	args   []interface{}
 }
 
-func (sm SayerMock) Say(ch, msg string, a ...interface{}) {
+func (sm *SayerMock) Say(ch, msg string, a ...interface{}) *SentMessage {
 	sm.ch = ch
 	sm.msg = msg
 	sm.args = a
+	return nil
+}
+
+func (sm *SayerMock) SayInThread(ch, thread, msg string, a ...interface{}) *SentMessage {
+	sm.thread = thread
+	return sm.Say(ch, msg, a...)
 }
 
 func TestConversation(t *testing.T) {
@@ -28,7 +36,7 @@ func TestConversation(t *testing.T) {
 
 	match, _ := command.Match(msg.Text())
 
-	conv := NewConversation(match, msg, &SayerMock{})
+	conv := NewConversation(context.Background(), match, msg, &SayerMock{})
 
 	str, err := conv.String("param")
 
@@ -53,7 +61,77 @@ func TestConnect(t *testing.T) {
 
 	match, _ := cmd.Match(msg.Text())
 
-	conv := NewConversation(match, msg, &SayerMock{})
+	conv := NewConversation(context.Background(), match, msg, &SayerMock{})
 
 	conv.Reply("example")
 }
+
+func TestReplyInThread(t *testing.T) {
+	var data = []struct {
+		ts     string
+		thread string
+		out    string
+	}{
+		{"1.1", "", "1.1"},
+		{"1.2", "1.1", "1.1"},
+	}
+
+	for _, set := range data {
+		msg := Message{ChannelID: "C1", UserID: "U1", TimeStamp: set.ts, ThreadTimeStamp: set.thread}
+		sayer := &SayerMock{}
+
+		conv := NewConversation(context.Background(), dummyMatch{}, msg, sayer)
+		conv.ReplyInThread("example")
+
+		if sayer.ch != "C1" || sayer.thread != set.out {
+			t.Errorf("Reply should be posted in thread %s of C1, was %s of %s", set.out, sayer.thread, sayer.ch)
+		}
+
+		if sayer.msg != "example" {
+			t.Errorf("Reply should not mention the user in a thread: %s", sayer.msg)
+		}
+	}
+}
+
+func TestAskWithoutBot(t *testing.T) {
+	conv := NewConversation(context.Background(), dummyMatch{}, Message{}, &SayerMock{})
+
+	if _, err := conv.Ask(context.Background(), "Which env?"); err != ErrNoBot {
+		t.Errorf("Ask without a bot should fail with ErrNoBot, got %v", err)
+	}
+}
+
+func TestConversationContext(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "event")
+
+	if conv := NewConversation(ctx, dummyMatch{}, Message{}, &SayerMock{}); conv.Context().Value(key{}) != "event" {
+		t.Errorf("Context should return the context the conversation was created with")
+	}
+
+	if conv := NewConversation(nil, dummyMatch{}, Message{}, &SayerMock{}); conv.Context() == nil {
+		t.Errorf("Context should not be nil")
+	}
+}
+
+func TestReplyBlocksWithoutBot(t *testing.T) {
+	sayer := &SayerMock{}
+	conv := NewConversation(context.Background(), dummyMatch{}, Message{ChannelID: "D1"}, sayer)
+
+	conv.ReplyBlocks(NewBlocks().Section("all good").Divider())
+	if len(sayer.args) != 1 || sayer.args[0] != "all good" {
+		t.Errorf("ReplyBlocks without a bot should reply with the fallback text, got %v", sayer.args)
+	}
+}
+
+func TestReplyEphemeralWithoutBot(t *testing.T) {
+	sayer := &SayerMock{}
+	conv := NewConversation(context.Background(), dummyMatch{}, Message{ChannelID: "C1"}, sayer)
+
+	if err := conv.ReplyEphemeral("secret"); err != ErrNoBot {
+		t.Errorf("ReplyEphemeral without a bot should fail, got %v", err)
+	}
+	if sayer.msg != "" {
+		t.Errorf("ReplyEphemeral without a bot should not reply publicly, replied \"%s\"", sayer.msg)
+	}
+}
@@ -0,0 +1,78 @@
+package hanu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/slack-go/slack"
+)
+
+// ephemeralUnavailable are the errors of ephemeral messages that cannot be
+// posted in the channel, but can be sent to the user directly
+var ephemeralUnavailable = map[string]bool{
+	"not_in_channel":      true,
+	"channel_not_found":   true,
+	"user_not_in_channel": true,
+}
+
+// SayEphemeral will cause the bot to post a message in the specified channel
+// that only the given user can see. If the bot or the user is not a member
+// of the channel the message is sent to the user directly instead, other
+// errors are returned.
+func (b *Bot) SayEphemeral(channel, user, msg string, a ...interface{}) error {
+	return b.sayEphemeral(context.Background(), channel, user, fmt.Sprintf(msg, a...))
+}
+
+// SayEphemeralInThread will cause the bot to post a message only the given
+// user can see in the thread of the message with the given timestamp,
+// falling back to a direct message like SayEphemeral
+func (b *Bot) SayEphemeralInThread(channel, threadTS, user, msg string, a ...interface{}) error {
+	return b.sayEphemeral(context.Background(), channel, user, fmt.Sprintf(msg, a...), slack.MsgOptionTS(threadTS))
+}
+
+// sayEphemeralInThread posts an ephemeral message within ctx, in the thread
+// with the given timestamp unless it is empty
+func (b *Bot) sayEphemeralInThread(ctx context.Context, channel, threadTS, user, msg string, a ...interface{}) error {
+	if threadTS == "" {
+		return b.sayEphemeral(ctx, channel, user, fmt.Sprintf(msg, a...))
+	}
+
+	return b.sayEphemeral(ctx, channel, user, fmt.Sprintf(msg, a...), slack.MsgOptionTS(threadTS))
+}
+
+func (b *Bot) sayEphemeral(ctx context.Context, channel, user, text string, options ...slack.MsgOption) error {
+	options = append(options, slack.MsgOptionText(text, false))
+
+	_, err := b.SocketClient.PostEphemeralContext(ctx, channel, user, options...)
+
+	var slackErr slack.SlackErrorResponse
+	if !errors.As(err, &slackErr) || !ephemeralUnavailable[slackErr.Err] {
+		return err
+	}
+
+	b.log().Debug("ephemeral message not possible, sending direct message", "channel", channel, "user", user, "error", err)
+
+	return b.sayDirect(ctx, user, text)
+}
+
+// sayDirect sends a message to the user's direct message channel and waits
+// until it was posted
+func (b *Bot) sayDirect(ctx context.Context, user, text string) error {
+	ch, _, _, err := b.SocketClient.OpenConversationContext(ctx, &slack.OpenConversationParameters{Users: []string{user}})
+	if err != nil {
+		return err
+	}
+	if ch == nil {
+		return ErrNotPosted
+	}
+
+	return b.Say(ch.ID, "%s", text).Wait()
+}
+
+// SetEphemeralHelp will make the auto-generated help only visible to the
+// user who asked for it
+func (b *Bot) SetEphemeralHelp(eh bool) *Bot {
+	b.ephemeralHelp = eh
+	return b
+}
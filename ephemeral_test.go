@@ -0,0 +1,96 @@
+package hanu_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ChrisMcKee/hanu"
+	"github.com/ChrisMcKee/hanu/hanutest"
+)
+
+func TestBotReplyEphemeral(t *testing.T) {
+	h := hanutest.New(t)
+	errs := make(chan error, 1)
+	h.Bot.Command("token", func(conv hanu.Convo) {
+		errs <- conv.ReplyEphemeral("your token is %s", "secret")
+	})
+
+	h.Message("U1", "C1", "token")
+	if err := <-errs; err != nil {
+		t.Fatalf("ephemeral reply should be posted, got %v", err)
+	}
+
+	calls := h.Server.Calls("chat.postEphemeral")
+	if len(calls) != 1 {
+		t.Fatalf("ephemeral reply should be posted once, was posted %d times", len(calls))
+	}
+
+	v := calls[0].Values
+	if v.Get("channel") != "C1" || v.Get("user") != "U1" || v.Get("text") != "your token is secret" {
+		t.Errorf("ephemeral reply should be posted to U1 in C1, got %v", v)
+	}
+
+	h.NoPost(50 * time.Millisecond)
+}
+
+func TestBotReplyEphemeralFallsBackToDirectMessage(t *testing.T) {
+	h := hanutest.New(t)
+	h.Server.Handle("chat.postEphemeral", func(call hanutest.Call) interface{} {
+		return map[string]interface{}{"ok": false, "error": "user_not_in_channel"}
+	})
+	h.Server.Handle("conversations.open", func(call hanutest.Call) interface{} {
+		return map[string]interface{}{"ok": true, "channel": map[string]interface{}{"id": "D" + call.Values.Get("users")}}
+	})
+
+	errs := make(chan error, 1)
+	h.Bot.Command("token", func(conv hanu.Convo) {
+		errs <- conv.ReplyEphemeral("your token is secret")
+	})
+
+	h.Message("U1", "C1", "token")
+	if err := <-errs; err != nil {
+		t.Fatalf("direct message should be sent, got %v", err)
+	}
+
+	if post := h.NextPost(); post.Channel != "DU1" || post.Text != "your token is secret" {
+		t.Errorf("reply should be sent to DU1, got \"%s\" in %s", post.Text, post.Channel)
+	}
+}
+
+func TestBotReplyEphemeralFailure(t *testing.T) {
+	h := hanutest.New(t)
+	h.Server.Handle("chat.postEphemeral", func(call hanutest.Call) interface{} {
+		return map[string]interface{}{"ok": false, "error": "invalid_blocks"}
+	})
+
+	errs := make(chan error, 1)
+	h.Bot.Command("token", func(conv hanu.Convo) {
+		errs <- conv.ReplyEphemeral("your token is secret")
+	})
+
+	h.Message("U1", "C1", "token")
+	if err := <-errs; err == nil || err.Error() != "invalid_blocks" {
+		t.Errorf("other errors should be returned, got %v", err)
+	}
+
+	if calls := h.Server.Calls("conversations.open"); len(calls) != 0 {
+		t.Errorf("other errors should not fall back to a direct message")
+	}
+	h.NoPost(50 * time.Millisecond)
+}
+
+func TestBotEphemeralHelp(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.SetEphemeralHelp(true)
+	h.Bot.Command("ping", func(conv hanu.Convo) {})
+
+	h.Mention("U1", "C1", "help")
+
+	h.NoPost(50 * time.Millisecond)
+
+	calls := h.Server.Calls("chat.postEphemeral")
+	if len(calls) != 1 || calls[0].Values.Get("user") != "U1" || !strings.Contains(calls[0].Values.Get("text"), "ping") {
+		t.Errorf("help should be posted ephemerally to U1, got %v", calls)
+	}
+}
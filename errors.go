@@ -0,0 +1,67 @@
+package hanu
+
+import (
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// ErrorHandler is called with the conversation of a command whose handler
+// returned an error or panicked
+type ErrorHandler func(conv Convo, err error)
+
+// PanicError is reported to the ErrorHandler when a handler panics
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+// Error returns the panic value
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("handler panicked: %v", e.Value)
+}
+
+// SetErrorHandler will set the function failing commands are reported to,
+// by default they are logged
+func (b *Bot) SetErrorHandler(h ErrorHandler) *Bot {
+	b.errorHandler = h
+	return b
+}
+
+// SetErrorReply will make the bot reply with the given text when a command
+// fails, there is no reply by default
+func (b *Bot) SetErrorReply(text string) *Bot {
+	b.errorReply = text
+	return b
+}
+
+// dispatch runs the handler of a command wrapped in the bot's middleware and
+// recovers from panics in either of them
+func (b *Bot) dispatch(command string, h Handler, conv Convo) {
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			b.metrics.handlerFailed(command)
+			b.fail(conv, &PanicError{Value: r, Stack: debug.Stack()})
+		}
+
+		b.metrics.handled(command, time.Since(start))
+	}()
+
+	b.wrap(h)(conv)
+}
+
+// fail reports the error of a command and replies to the user if set up to
+func (b *Bot) fail(conv Convo, err error) {
+	markFailed(conv)
+
+	if b.errorHandler != nil {
+		b.errorHandler(conv, err)
+	} else {
+		b.log().Error("command failed", append(messageAttrs(conv.Message()), "text", conv.Message().Text(), "error", err)...)
+	}
+
+	if b.errorReply != "" {
+		conv.Reply("%s", b.errorReply)
+	}
+}
@@ -0,0 +1,82 @@
+package hanu_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ChrisMcKee/hanu"
+	"github.com/ChrisMcKee/hanu/hanutest"
+)
+
+func TestErrorHandler(t *testing.T) {
+	reported := make(chan error, 1)
+
+	h := hanutest.New(t)
+	h.Bot.SetErrorHandler(func(conv hanu.Convo, err error) {
+		reported <- err
+	})
+	h.Bot.SetErrorReply("Sorry, that did not work")
+	h.Bot.CommandE("deploy", func(conv hanu.Convo) error {
+		return errors.New("no such service")
+	})
+
+	h.Message("U1", "D1", "deploy")
+
+	if post := h.NextPost(); post.Text != "Sorry, that did not work" {
+		t.Errorf("bot should reply with the error reply, got \"%s\"", post.Text)
+	}
+
+	if err := <-reported; err.Error() != "no such service" {
+		t.Errorf("error should be reported, got %v", err)
+	}
+}
+
+func TestPanicRecovery(t *testing.T) {
+	reported := make(chan error, 1)
+
+	h := hanutest.New(t)
+	h.Bot.SetErrorHandler(func(conv hanu.Convo, err error) {
+		reported <- err
+	})
+	h.Bot.Command("boom", func(conv hanu.Convo) {
+		panic("boom")
+	})
+	h.Bot.Command("ping", func(conv hanu.Convo) {
+		conv.Reply("pong")
+	})
+
+	h.Message("U1", "D1", "boom")
+
+	var panicErr *hanu.PanicError
+	if err := <-reported; !errors.As(err, &panicErr) || panicErr.Value != "boom" {
+		t.Errorf("panic should be reported as PanicError, got %v", err)
+	} else if len(panicErr.Stack) == 0 {
+		t.Errorf("PanicError should carry the stack")
+	}
+
+	h.Message("U1", "D1", "ping")
+	if post := h.NextPost(); post.Text != "pong" {
+		t.Errorf("bot should keep working after a panic, got \"%s\"", post.Text)
+	}
+}
+
+func TestPanicInMiddleware(t *testing.T) {
+	reported := make(chan error, 1)
+
+	h := hanutest.New(t)
+	h.Bot.SetErrorHandler(func(conv hanu.Convo, err error) {
+		reported <- err
+	})
+	h.Bot.SetReplyOnly(true).UnknownCommand(func(conv hanu.Convo) {})
+	h.Bot.Use(func(next hanu.Handler) hanu.Handler {
+		return func(conv hanu.Convo) {
+			panic("middleware")
+		}
+	})
+
+	h.Message("U1", "D1", "nope")
+
+	if err := <-reported; err.Error() != "handler panicked: middleware" {
+		t.Errorf("panic in middleware should be reported, got %v", err)
+	}
+}
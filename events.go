@@ -0,0 +1,194 @@
+package hanu
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/slack-go/slack/socketmode"
+)
+
+// httpEnvelopePrefix marks the envelope IDs of events received over HTTP
+const httpEnvelopePrefix = "http-"
+
+// eventHandler handles an event, ctx is cancelled when the transport the event
+// was received over stops or the bot shuts down
+type eventHandler func(ctx context.Context, evt *socketmode.Event, client *socketmode.Client)
+
+// withoutContext adapts a socket mode handler to an eventHandler
+func withoutContext(h socketmode.SocketmodeHandlerFunc) eventHandler {
+	return func(ctx context.Context, evt *socketmode.Event, client *socketmode.Client) {
+		h(evt, client)
+	}
+}
+
+// eventRouter dispatches events received over socket mode or HTTP to the
+// registered handlers, the same way socketmode.SocketmodeHandler does
+type eventRouter struct {
+	events        map[socketmode.EventType][]eventHandler
+	interactions  map[slack.InteractionType][]eventHandler
+	eventsAPI     map[slackevents.EventsAPIType][]eventHandler
+	slashCommands map[string]eventHandler
+	// interaction returns the handlers an interaction is run by given the
+	// handlers of its type, it is called before any of them runs
+	interaction func(evt *socketmode.Event, handlers []eventHandler) []eventHandler
+	inflight    inflight
+}
+
+func newEventRouter() *eventRouter {
+	return &eventRouter{
+		events:        make(map[socketmode.EventType][]eventHandler),
+		interactions:  make(map[slack.InteractionType][]eventHandler),
+		eventsAPI:     make(map[slackevents.EventsAPIType][]eventHandler),
+		slashCommands: make(map[string]eventHandler),
+	}
+}
+
+// dispatch runs the handlers registered for the event within a context
+// derived from ctx and reports whether there were any
+func (r *eventRouter) dispatch(ctx context.Context, evt socketmode.Event, client *socketmode.Client) bool {
+	handled := r.run(ctx, r.events[evt.Type], &evt, client)
+
+	switch data := evt.Data.(type) {
+	case slack.InteractionCallback:
+		handlers := r.interactions[data.Type]
+		if r.interaction != nil {
+			handlers = r.interaction(&evt, handlers)
+		}
+
+		handled = r.run(ctx, handlers, &evt, client) || handled
+	case slackevents.EventsAPIEvent:
+		handled = r.run(ctx, r.eventsAPI[slackevents.EventsAPIType(data.InnerEvent.Type)], &evt, client) || handled
+	case slack.SlashCommand:
+		if h, ok := r.slashCommands[data.Command]; ok {
+			handled = r.run(ctx, []eventHandler{h}, &evt, client) || handled
+		}
+	}
+
+	return handled
+}
+
+func (r *eventRouter) run(ctx context.Context, handlers []eventHandler, evt *socketmode.Event, client *socketmode.Client) bool {
+	for _, h := range handlers {
+		ctx, done, ok := r.inflight.start(ctx)
+		if !ok {
+			continue
+		}
+
+		go func(h eventHandler) {
+			defer done()
+			h(ctx, evt, client)
+		}(h)
+	}
+
+	return len(handlers) > 0
+}
+
+// acks keeps track of events received over HTTP waiting for an acknowledgement
+type acks struct {
+	mu      sync.Mutex
+	pending map[string]chan interface{}
+}
+
+// wait registers an envelope ID, the returned function must be called once
+// the response was written
+func (a *acks) wait(id string) (<-chan interface{}, func()) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.pending == nil {
+		a.pending = make(map[string]chan interface{})
+	}
+
+	ch := make(chan interface{}, 1)
+	a.pending[id] = ch
+
+	return ch, func() {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+
+		delete(a.pending, id)
+	}
+}
+
+// ack passes the payload to the waiting HTTP response, only the first
+// acknowledgement of an envelope is used
+func (a *acks) ack(id string, payload interface{}) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	ch, ok := a.pending[id]
+	if !ok {
+		return
+	}
+
+	delete(a.pending, id)
+	ch <- payload
+}
+
+// Ack acknowledges an event received over socket mode or HTTP, optionally
+// with a response payload. Handlers should use it instead of the socket mode
+// client's Ack to work with both transports.
+func (b *Bot) Ack(req socketmode.Request, payload ...interface{}) {
+	var pld interface{}
+	if len(payload) > 0 {
+		pld = payload[0]
+	}
+
+	if !b.interactions.acks.claim(req.EnvelopeID) {
+		return
+	}
+
+	if strings.HasPrefix(req.EnvelopeID, httpEnvelopePrefix) {
+		b.acks.ack(req.EnvelopeID, pld)
+		return
+	}
+
+	b.SocketClient.Ack(req, payload...)
+}
+
+// listen registers the bot's own event handlers, it is called once by the
+// first transport started
+func (b *Bot) listen() {
+	b.listenOnce.Do(func() {
+		b.router.events[socketmode.EventTypeConnecting] = append(b.router.events[socketmode.EventTypeConnecting], middlewareConnecting(b))
+		b.router.events[socketmode.EventTypeConnectionError] = append(b.router.events[socketmode.EventTypeConnectionError], middlewareConnectionError(b))
+		b.router.events[socketmode.EventTypeConnected] = append(b.router.events[socketmode.EventTypeConnected], middlewareConnected(b))
+
+		// Handle a specific event from EventsAPI
+		b.router.eventsAPI[slackevents.AppMention] = append(b.router.eventsAPI[slackevents.AppMention], middlewareAppMentionEventWithBot(b))
+		b.router.eventsAPI[slackevents.Message] = append(b.router.eventsAPI[slackevents.Message], middlewareMessageEventWithBot(b))
+		b.router.interaction = middlewareInteraction(b)
+		b.router.eventsAPI[slackevents.ReactionAdded] = append(b.router.eventsAPI[slackevents.ReactionAdded], middlewareReactionEventWithBot(b))
+		b.router.eventsAPI[slackevents.ReactionRemoved] = append(b.router.eventsAPI[slackevents.ReactionRemoved], middlewareReactionEventWithBot(b))
+
+		b.listenerEnabled = true
+	})
+}
+
+// runEventLoop dispatches the events received over socket mode
+func (b *Bot) runEventLoop(ctx context.Context) {
+	for {
+		select {
+		case evt, ok := <-b.SocketClient.Events:
+			if !ok {
+				return
+			}
+			b.metrics.eventReceived(evt)
+
+			// Unacknowledged events are delivered again after reconnecting
+			if b.router.inflight.isStopped() {
+				continue
+			}
+
+			if !b.router.dispatch(ctx, evt, b.SocketClient) {
+				b.log().Debug("unhandled event", "event_type", evt.Type)
+			}
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package hanu
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/slack-go/slack"
+)
+
+// File is a file attached to a message
+type File struct {
+	ID       string
+	Name     string
+	Title    string
+	Mimetype string
+	// Filetype is Slack's name of the file's type, e.g. "csv"
+	Filetype string
+	Size     int
+	// URL is where the file can be downloaded with the bot token
+	URL string
+}
+
+// newFiles converts the files attached to a message
+func newFiles(files []slack.File) []File {
+	if len(files) == 0 {
+		return nil
+	}
+
+	converted := make([]File, len(files))
+	for i, f := range files {
+		converted[i] = File{
+			ID:       f.ID,
+			Name:     f.Name,
+			Title:    f.Title,
+			Mimetype: f.Mimetype,
+			Filetype: f.Filetype,
+			Size:     f.Size,
+			URL:      f.URLPrivateDownload,
+		}
+		if converted[i].URL == "" {
+			converted[i].URL = f.URLPrivate
+		}
+	}
+
+	return converted
+}
+
+// DownloadFile writes the content of a file attached to a message to w,
+// authenticated with the bot token
+func (b *Bot) DownloadFile(ctx context.Context, file File, w io.Writer) error {
+	return b.SocketClient.GetFileContext(ctx, file.URL, w)
+}
+
+// UploadFile uploads the content of r as a file with the given name and
+// shares it in the channel, in the thread of threadTS unless it is empty
+func (b *Bot) UploadFile(ctx context.Context, channel, threadTS, name string, r io.Reader) error {
+	// Slack needs to know the size before the upload
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.SocketClient.UploadFileV2Context(ctx, slack.UploadFileV2Parameters{
+		Reader:          bytes.NewReader(content),
+		FileSize:        len(content),
+		Filename:        name,
+		Title:           name,
+		Channel:         channel,
+		ThreadTimestamp: threadTS,
+	})
+
+	return err
+}
@@ -0,0 +1,95 @@
+package hanu_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ChrisMcKee/hanu"
+	"github.com/ChrisMcKee/hanu/hanutest"
+)
+
+func TestConversationDownload(t *testing.T) {
+	files := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer xoxb-hanutest" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("service,status\napi,ok\n"))
+	}))
+	defer files.Close()
+
+	h := hanutest.New(t)
+	downloads := make(chan string, 1)
+	h.Bot.CommandE("import", func(conv hanu.Convo) error {
+		var buf bytes.Buffer
+		for _, f := range conv.Message().Files() {
+			if err := conv.Download(f, &buf); err != nil {
+				return err
+			}
+		}
+		downloads <- buf.String()
+		return nil
+	})
+
+	h.Event(map[string]interface{}{
+		"type":         "message",
+		"user":         "U1",
+		"text":         "import",
+		"ts":           "1600000000.000001",
+		"channel":      "C1",
+		"channel_type": "channel",
+		"files": []map[string]interface{}{
+			{"id": "F1", "name": "status.csv", "filetype": "csv", "url_private_download": files.URL + "/status.csv"},
+		},
+	}).Ack()
+
+	if content := <-downloads; content != "service,status\napi,ok\n" {
+		t.Errorf("attached file should be downloaded with the bot token, got \"%s\"", content)
+	}
+}
+
+func TestConversationReplyFile(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.SetThreadReplies(true)
+	h.Server.Handle("files.getUploadURLExternal", func(call hanutest.Call) interface{} {
+		return map[string]interface{}{"ok": true, "upload_url": h.Server.APIURL() + "upload", "file_id": "F1"}
+	})
+	h.Server.Handle("files.completeUploadExternal", func(call hanutest.Call) interface{} {
+		return map[string]interface{}{"ok": true, "files": []map[string]interface{}{{"id": "F1"}}}
+	})
+
+	errs := make(chan error, 1)
+	h.Bot.Command("report", func(conv hanu.Convo) {
+		errs <- conv.ReplyFile("report.csv", strings.NewReader("service,status\napi,ok\n"))
+	})
+
+	ts := h.Message("U1", "C1", "root")
+	h.ThreadMessage("U1", "C1", ts, "report")
+	if err := <-errs; err != nil {
+		t.Fatalf("file should be uploaded, got %v", err)
+	}
+
+	if calls := h.Server.Calls("files.getUploadURLExternal"); len(calls) != 1 || calls[0].Values.Get("filename") != "report.csv" || calls[0].Values.Get("length") != "22" {
+		t.Errorf("upload URL should be requested for report.csv of 22 bytes, got %v", calls)
+	}
+
+	if calls := h.Server.Calls("upload"); len(calls) != 1 || !bytes.Contains(calls[0].Body, []byte("service,status\napi,ok\n")) {
+		t.Errorf("content should be uploaded, got %v", calls)
+	}
+
+	calls := h.Server.Calls("files.completeUploadExternal")
+	if len(calls) != 1 || calls[0].Values.Get("channel_id") != "C1" || calls[0].Values.Get("thread_ts") != ts {
+		t.Errorf("file should be shared in thread %s of C1, got %v", ts, calls)
+	}
+}
+
+func TestReplyFileWithoutBot(t *testing.T) {
+	conv := hanu.NewConversation(context.Background(), nil, hanu.Message{ChannelID: "C1"}, nil)
+	if err := conv.ReplyFile("report.csv", strings.NewReader("")); err != hanu.ErrNoBot {
+		t.Errorf("ReplyFile without a bot should fail, got %v", err)
+	}
+}
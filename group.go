@@ -0,0 +1,280 @@
+package hanu
+
+import (
+	"strings"
+	"time"
+
+	"github.com/ChrisMcKee/allot"
+)
+
+// CommandGroup groups commands sharing their leading words, e.g. "deploy"
+// for "deploy list" and "deploy start <service>". Groups can be nested and
+// have their own middleware and permissions.
+type CommandGroup struct {
+	name        string
+	description string
+	prefix      string
+	parent      *CommandGroup
+	commands    []CommandInterface
+	groups      []*CommandGroup
+	middleware  []Middleware
+	permissions Permissions
+}
+
+// NewCommandGroup creates a new command group
+func NewCommandGroup(name string, description string) *CommandGroup {
+	return &CommandGroup{
+		name:        name,
+		description: description,
+	}
+}
+
+// Name returns the name of the group, including the names of its parents
+func (g *CommandGroup) Name() string {
+	if g.parent == nil {
+		return g.prefix + g.name
+	}
+
+	return g.parent.Name() + " " + g.name
+}
+
+// Description returns the description
+func (g *CommandGroup) Description() string {
+	return g.description
+}
+
+// SetDescription sets the description
+func (g *CommandGroup) SetDescription(text string) {
+	g.description = text
+}
+
+// Permissions returns who can run the group's commands and where
+func (g *CommandGroup) Permissions() Permissions {
+	return g.permissions
+}
+
+// SetPermissions restricts who can run the group's commands and where, a
+// command must be allowed by its own permissions and those of all its groups
+func (g *CommandGroup) SetPermissions(p Permissions) {
+	g.permissions = p
+}
+
+// Use adds middleware around the group's commands, it runs inside the bot's
+// middleware and the middleware of parent groups
+func (g *CommandGroup) Use(mw ...Middleware) *CommandGroup {
+	g.middleware = append(g.middleware, mw...)
+	return g
+}
+
+// Command adds a new subcommand with custom handler
+func (g *CommandGroup) Command(cmd string, handler Handler) {
+	g.commands = append(g.commands, NewCommand(cmd, "", handler))
+}
+
+// CommandE adds a new subcommand with a handler that can fail
+func (g *CommandGroup) CommandE(cmd string, handler HandlerE) {
+	g.commands = append(g.commands, NewCommandE(cmd, "", handler))
+}
+
+// Register registers a subcommand, its text must not repeat the group's name
+func (g *CommandGroup) Register(cmd CommandInterface) {
+	g.commands = append(g.commands, cmd)
+}
+
+// Group adds a nested group and returns it
+func (g *CommandGroup) Group(name string, description string) *CommandGroup {
+	sub := NewCommandGroup(name, description)
+	sub.parent = g
+	g.groups = append(g.groups, sub)
+
+	return sub
+}
+
+// Groups returns the nested groups
+func (g *CommandGroup) Groups() []*CommandGroup {
+	return g.groups
+}
+
+// root returns the top level group
+func (g *CommandGroup) root() *CommandGroup {
+	for g.parent != nil {
+		g = g.parent
+	}
+
+	return g
+}
+
+// flatten returns the commands of the group and its nested groups, with the
+// group names in front of their texts
+func (g *CommandGroup) flatten() []CommandInterface {
+	var cmds []CommandInterface
+	for _, cmd := range g.commands {
+		cmds = append(cmds, groupCommand{
+			CommandInterface: cmd,
+			group:            g,
+			command:          allot.New(g.Name() + " " + cmd.Get().Text()),
+		})
+	}
+
+	for _, sub := range g.groups {
+		cmds = append(cmds, sub.flatten()...)
+	}
+
+	return cmds
+}
+
+// RegisterGroup registers the commands of a group and its nested groups,
+// commands added to the group afterwards are not registered
+func (b *Bot) RegisterGroup(g *CommandGroup) {
+	g.root().prefix = b.CmdPrefix
+	b.Commands = append(b.Commands, g.root().flatten()...)
+	b.commandRouter.invalidate()
+}
+
+// groupCommand is a command registered as part of a group
+type groupCommand struct {
+	CommandInterface
+	group   *CommandGroup
+	command allot.CommandInterface
+}
+
+// Get returns the command including the names of its groups
+func (c groupCommand) Get() allot.CommandInterface {
+	return c.command
+}
+
+// Handle calls the command's handler
+func (c groupCommand) Handle(conv ConversationInterface) {
+	c.HandleE(conv)
+}
+
+// HandleE calls the command's handler within the middleware of its groups
+func (c groupCommand) HandleE(conv ConversationInterface) error {
+	var err error
+	h := Handler(func(conv Convo) {
+		e, ok := c.CommandInterface.(interface {
+			HandleE(conv ConversationInterface) error
+		})
+		if !ok {
+			c.CommandInterface.Handle(conv)
+			return
+		}
+
+		err = e.HandleE(conv)
+	})
+
+	for g := c.group; g != nil; g = g.parent {
+		for i := len(g.middleware) - 1; i >= 0; i-- {
+			h = g.middleware[i](h)
+		}
+	}
+
+	h(conv)
+	return err
+}
+
+// ParameterDescription returns the description of a parameter
+func (c groupCommand) ParameterDescription(name string) string {
+	if d, ok := c.CommandInterface.(interface {
+		ParameterDescription(name string) string
+	}); ok {
+		return d.ParameterDescription(name)
+	}
+
+	return ""
+}
+
+// Examples returns the example requests
+func (c groupCommand) Examples() []string {
+	if d, ok := c.CommandInterface.(interface {
+		Examples() []string
+	}); ok {
+		return d.Examples()
+	}
+
+	return nil
+}
+
+// Timeout returns how long the command may run
+func (c groupCommand) Timeout() time.Duration {
+	if t, ok := c.CommandInterface.(interface {
+		Timeout() time.Duration
+	}); ok {
+		return t.Timeout()
+	}
+
+	return 0
+}
+
+// Permissions returns the command's own permissions
+func (c groupCommand) Permissions() Permissions {
+	if p, ok := c.CommandInterface.(interface {
+		Permissions() Permissions
+	}); ok {
+		return p.Permissions()
+	}
+
+	return Permissions{}
+}
+
+// permissionSets returns the permissions of the command and all its groups,
+// which all have to allow a request
+func (c groupCommand) permissionSets() []Permissions {
+	sets := []Permissions{c.Permissions()}
+	for g := c.group; g != nil; g = g.parent {
+		sets = append(sets, g.permissions)
+	}
+
+	return sets
+}
+
+// buildGroupHelpText lists the commands of a group and its nested groups
+// indented below the group's name, groups without commands to show are left out
+func (b *Bot) buildGroupHelpText(g *CommandGroup, show func(cmd CommandInterface) bool, depth int) string {
+	lines := ""
+	for _, cmd := range b.Commands {
+		gc, ok := cmd.(groupCommand)
+		if !ok || gc.group != g || !show(cmd) {
+			continue
+		}
+
+		lines = lines + strings.Repeat("    ", depth) + "  • " + helpLine("", cmd)
+	}
+
+	for _, sub := range g.groups {
+		lines = lines + b.buildGroupHelpText(sub, show, depth+1)
+	}
+
+	if lines == "" {
+		return ""
+	}
+
+	help := "`" + g.Name() + "`"
+	if depth > 0 {
+		help = strings.Repeat("    ", depth-1) + "  • " + help
+	}
+	if g.description != "" {
+		help = help + " *–* " + g.description
+	}
+
+	return help + "\n" + lines
+}
+
+// groupFor returns the registered group with the given name
+func (b *Bot) groupFor(name string) (*CommandGroup, bool) {
+	name = strings.Join(strings.Fields(name), " ")
+	for _, cmd := range b.Commands {
+		gc, ok := cmd.(groupCommand)
+		if !ok {
+			continue
+		}
+
+		for g := gc.group; g != nil; g = g.parent {
+			if g.Name() == name || strings.TrimPrefix(g.Name(), b.CmdPrefix) == name {
+				return g, true
+			}
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,149 @@
+package hanu_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ChrisMcKee/hanu"
+	"github.com/ChrisMcKee/hanu/hanutest"
+)
+
+func newDeployGroup() *hanu.CommandGroup {
+	deploy := hanu.NewCommandGroup("deploy", "Manage deployments")
+	deploy.Register(hanu.NewCommand("list", "List the deployments", func(conv hanu.Convo) {
+		conv.Reply("nothing deployed")
+	}))
+	deploy.Register(hanu.NewCommand("start <service>", "Start a deployment", func(conv hanu.Convo) {
+		service, _ := conv.String("service")
+		conv.Reply("deploying " + service)
+	}))
+
+	db := deploy.Group("db", "Manage database migrations")
+	db.Register(hanu.NewCommand("migrate", "Run the migrations", func(conv hanu.Convo) {
+		conv.Reply("migrating")
+	}))
+
+	return deploy
+}
+
+func TestGroupCommands(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.RegisterGroup(newDeployGroup())
+
+	h.Message("U1", "D1", "deploy start api")
+	if post := h.NextPost(); post.Text != "deploying api" {
+		t.Errorf("reply should be \"deploying api\", is \"%s\"", post.Text)
+	}
+
+	h.Message("U1", "D1", "deploy db migrate")
+	if post := h.NextPost(); post.Text != "migrating" {
+		t.Errorf("reply should be \"migrating\", is \"%s\"", post.Text)
+	}
+
+	h.Message("U1", "D1", "start api")
+	h.NoPost(100 * time.Millisecond)
+}
+
+func TestGroupCommandPrefix(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.SetCommandPrefix("!")
+	h.Bot.RegisterGroup(newDeployGroup())
+
+	h.Message("U1", "D1", "!deploy list")
+	if post := h.NextPost(); post.Text != "nothing deployed" {
+		t.Errorf("reply should be \"nothing deployed\", is \"%s\"", post.Text)
+	}
+}
+
+func TestGroupMiddleware(t *testing.T) {
+	h := hanutest.New(t)
+
+	var order []string
+	h.Bot.Use(func(next hanu.Handler) hanu.Handler {
+		return func(conv hanu.Convo) {
+			order = append(order, "bot")
+			next(conv)
+		}
+	})
+
+	deploy := newDeployGroup()
+	deploy.Use(func(next hanu.Handler) hanu.Handler {
+		return func(conv hanu.Convo) {
+			order = append(order, "deploy")
+			next(conv)
+		}
+	})
+	deploy.Groups()[0].Use(func(next hanu.Handler) hanu.Handler {
+		return func(conv hanu.Convo) {
+			order = append(order, "db")
+			next(conv)
+		}
+	})
+	h.Bot.RegisterGroup(deploy)
+
+	h.Message("U1", "D1", "deploy db migrate")
+	h.NextPost()
+
+	if strings.Join(order, ",") != "bot,deploy,db" {
+		t.Errorf("middleware should run from the bot to the innermost group, ran %v", order)
+	}
+}
+
+func TestGroupPermissions(t *testing.T) {
+	h := hanutest.New(t)
+
+	deploy := newDeployGroup()
+	deploy.SetPermissions(hanu.Permissions{Users: []string{"UOPS", "UDBA"}})
+	deploy.Groups()[0].SetPermissions(hanu.Permissions{Users: []string{"UDBA"}})
+	h.Bot.RegisterGroup(deploy)
+
+	h.Message("U1", "D1", "deploy list")
+	if post := h.NextPost(); post.Text != "Sorry, you are not allowed to run this command." {
+		t.Errorf("U1 should be denied, got \"%s\"", post.Text)
+	}
+
+	h.Message("UOPS", "D1", "deploy list")
+	if post := h.NextPost(); post.Text != "nothing deployed" {
+		t.Errorf("UOPS should be allowed, got \"%s\"", post.Text)
+	}
+
+	h.Message("UOPS", "D1", "deploy db migrate")
+	if post := h.NextPost(); post.Text != "Sorry, you are not allowed to run this command." {
+		t.Errorf("UOPS should be denied by the nested group, got \"%s\"", post.Text)
+	}
+
+	h.Message("UDBA", "D1", "deploy db migrate")
+	if post := h.NextPost(); post.Text != "migrating" {
+		t.Errorf("UDBA should be allowed, got \"%s\"", post.Text)
+	}
+
+	h.Message("UOPS", "D1", "help")
+	if post := h.NextPost(); strings.Contains(post.Text, "migrate") || !strings.Contains(post.Text, "deploy list") {
+		t.Errorf("help should hide the db group from UOPS, got \"%s\"", post.Text)
+	}
+}
+
+func TestGroupHelp(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.Register(hanu.NewCommand("ping", "Ping the bot", func(conv hanu.Convo) {}))
+	h.Bot.RegisterGroup(newDeployGroup())
+
+	want := "The available commands are:\n\n" +
+		"`ping` *–* Ping the bot\n" +
+		"`deploy` *–* Manage deployments\n" +
+		"  • `deploy list` *–* List the deployments\n" +
+		"  • `deploy start <service>` *–* Start a deployment\n" +
+		"  • `deploy db` *–* Manage database migrations\n" +
+		"      • `deploy db migrate` *–* Run the migrations\n"
+
+	if help := h.Bot.BuildHelpText(); help != want {
+		t.Errorf("help should list the groups hierarchically, is \"%s\"", help)
+	}
+
+	h.Message("U1", "D1", "help deploy db")
+	post := h.NextPost()
+	if !strings.HasPrefix(post.Text, "`deploy db` *–* Manage database migrations\n\n`deploy db migrate`") {
+		t.Errorf("group help should describe the group and its commands, is \"%s\"", post.Text)
+	}
+}
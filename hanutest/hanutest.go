@@ -0,0 +1,296 @@
+// Package hanutest drives a hanu Bot against a fake Slack backend, so
+// commands, help output and interactions can be tested without Slack.
+//
+//	h := hanutest.New(t)
+//	h.Bot.Command("ping", func(conv hanu.Convo) { conv.Reply("pong") })
+//
+//	h.Message("U123", "D123", "ping")
+//	if post := h.NextPost(); post.Text != "pong" {
+//		t.Errorf("unexpected reply %q", post.Text)
+//	}
+package hanutest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ChrisMcKee/hanu"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/slack-go/slack/socketmode"
+)
+
+// Timeout is how long the harness waits for the bot to acknowledge or post
+var Timeout = 2 * time.Second
+
+// Harness connects a Bot to a fake Slack backend
+type Harness struct {
+	Bot    *hanu.Bot
+	Server *Server
+
+	t      testing.TB
+	ctx    context.Context
+	cancel context.CancelFunc
+	start  sync.Once
+	mu     sync.Mutex
+	ts     int
+}
+
+// Envelope is a socket mode request delivered to the bot
+type Envelope struct {
+	ID string
+
+	t   testing.TB
+	ack chan json.RawMessage
+}
+
+// New creates a bot connected to a fresh fake backend; both are shut down
+// when the test finishes. The bot's logs are discarded and messages are not
+// rate limited, use SetLogger and SetRateLimit to change that.
+func New(t testing.TB) *Harness {
+	t.Helper()
+
+	srv := NewServer()
+
+	api := slack.New(
+		"xoxb-hanutest",
+		slack.OptionAPIURL(srv.APIURL()),
+		slack.OptionAppLevelToken("xapp-hanutest"),
+	)
+	socketClient := socketmode.New(
+		api,
+		socketmode.OptionLog(log.New(io.Discard, "", 0)),
+	)
+
+	bot, err := hanu.NewWithClient(socketClient)
+	if err != nil {
+		srv.Close()
+		t.Fatalf("hanutest: creating bot: %v", err)
+	}
+	bot.SetLogger(slog.New(slog.DiscardHandler)).SetRateLimit(0, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h := &Harness{
+		Bot:    bot,
+		Server: srv,
+		t:      t,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	t.Cleanup(func() {
+		cancel()
+		srv.Close()
+	})
+
+	return h
+}
+
+// Start makes the bot listen on the fake backend. Everything that must be
+// registered before Listen has to be registered before calling Start;
+// sending the first event starts the bot implicitly.
+func (h *Harness) Start() {
+	h.t.Helper()
+
+	h.start.Do(func() {
+		go h.Bot.Listen(h.ctx)
+	})
+
+	if !h.Server.waitConnected(Timeout) {
+		h.t.Fatalf("hanutest: bot did not connect within %s", Timeout)
+	}
+}
+
+// Message delivers a message event from user in channel and waits until the
+// bot acknowledged it. It returns the timestamp of the message.
+func (h *Harness) Message(user, channel, text string) string {
+	h.t.Helper()
+
+	return h.ThreadMessage(user, channel, "", text)
+}
+
+// ThreadMessage delivers a message event posted in the thread of threadTS
+// and waits until the bot acknowledged it. It returns the timestamp of the
+// message.
+func (h *Harness) ThreadMessage(user, channel, threadTS, text string) string {
+	h.t.Helper()
+
+	ts := h.nextTimestamp()
+	h.Event(&slackevents.MessageEvent{
+		Type:            string(slackevents.Message),
+		User:            user,
+		Text:            text,
+		TimeStamp:       ts,
+		ThreadTimeStamp: threadTS,
+		Channel:         channel,
+		ChannelType:     channelType(channel),
+	}).Ack()
+
+	return ts
+}
+
+// Mention delivers an app_mention event addressing the bot and waits until
+// the bot acknowledged it. It returns the timestamp of the message.
+func (h *Harness) Mention(user, channel, text string) string {
+	h.t.Helper()
+
+	ts := h.nextTimestamp()
+	h.Event(&slackevents.AppMentionEvent{
+		Type:      string(slackevents.AppMention),
+		User:      user,
+		Text:      "<@" + h.Bot.ID + "> " + text,
+		TimeStamp: ts,
+		Channel:   channel,
+	}).Ack()
+
+	return ts
+}
+
+// Reaction delivers a reaction_added event of user reacting with the emoji
+// name to the message with timestamp ts, and waits until the bot
+// acknowledged it
+func (h *Harness) Reaction(user, channel, ts, name string) {
+	h.t.Helper()
+
+	h.Event(&slackevents.ReactionAddedEvent{
+		Type:           string(slackevents.ReactionAdded),
+		User:           user,
+		Reaction:       name,
+		Item:           slackevents.Item{Type: "message", Channel: channel, Timestamp: ts},
+		EventTimestamp: h.nextTimestamp(),
+	}).Ack()
+}
+
+// ReactionRemoved delivers a reaction_removed event and waits until the bot
+// acknowledged it
+func (h *Harness) ReactionRemoved(user, channel, ts, name string) {
+	h.t.Helper()
+
+	h.Event(&slackevents.ReactionRemovedEvent{
+		Type:           string(slackevents.ReactionRemoved),
+		User:           user,
+		Reaction:       name,
+		Item:           slackevents.Item{Type: "message", Channel: channel, Timestamp: ts},
+		EventTimestamp: h.nextTimestamp(),
+	}).Ack()
+}
+
+// Event delivers an Events API event, e.g. a *slackevents.MessageEvent
+func (h *Harness) Event(ev interface{}) *Envelope {
+	h.t.Helper()
+
+	inner, err := json.Marshal(ev)
+	if err != nil {
+		h.t.Fatalf("hanutest: encoding event: %v", err)
+	}
+
+	raw := json.RawMessage(inner)
+	return h.deliver(socketmode.RequestTypeEventsAPI, slackevents.EventsAPICallbackEvent{
+		Type:       slackevents.CallbackEvent,
+		TeamID:     "THANU",
+		APIAppID:   "AHANU",
+		InnerEvent: &raw,
+		EventID:    "Ev" + h.nextTimestamp(),
+		EventTime:  int(time.Now().Unix()),
+	})
+}
+
+// Interaction delivers an interaction payload such as a button click or a
+// dialog submission
+func (h *Harness) Interaction(cb slack.InteractionCallback) *Envelope {
+	h.t.Helper()
+
+	return h.deliver(socketmode.RequestTypeInteractive, cb)
+}
+
+// SlashCommand delivers a slash command invocation
+func (h *Harness) SlashCommand(cmd slack.SlashCommand) *Envelope {
+	h.t.Helper()
+
+	return h.deliver(socketmode.RequestTypeSlashCommands, cmd)
+}
+
+// NextPost waits for the next message the bot posts and fails the test if
+// none arrives within Timeout
+func (h *Harness) NextPost() Post {
+	h.t.Helper()
+
+	select {
+	case post := <-h.Server.postCh:
+		return post
+	case <-time.After(Timeout):
+		h.t.Fatalf("hanutest: bot did not post within %s", Timeout)
+	}
+
+	return Post{}
+}
+
+// NoPost fails the test if the bot posts anything within the given duration
+func (h *Harness) NoPost(d time.Duration) {
+	h.t.Helper()
+
+	select {
+	case post := <-h.Server.postCh:
+		h.t.Errorf("hanutest: unexpected post to %s: %q", post.Channel, post.Text)
+	case <-time.After(d):
+	}
+}
+
+func (h *Harness) deliver(typ string, payload interface{}) *Envelope {
+	h.t.Helper()
+
+	h.Start()
+
+	id, ack, err := h.Server.deliver(typ, payload)
+	if err != nil {
+		h.t.Fatalf("hanutest: delivering %s: %v", typ, err)
+	}
+
+	return &Envelope{ID: id, t: h.t, ack: ack}
+}
+
+func (h *Harness) nextTimestamp() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.ts++
+	return fmt.Sprintf("1600000000.%06d", h.ts)
+}
+
+// Ack waits for the bot to acknowledge the envelope and returns the payload
+// sent along with the acknowledgement; it fails the test on timeout
+func (e *Envelope) Ack() json.RawMessage {
+	e.t.Helper()
+
+	payload, ok := e.Acked(Timeout)
+	if !ok {
+		e.t.Fatalf("hanutest: %s was not acknowledged within %s", e.ID, Timeout)
+	}
+
+	return payload
+}
+
+// Acked waits up to d for the acknowledgement and reports whether it arrived
+func (e *Envelope) Acked(d time.Duration) (json.RawMessage, bool) {
+	select {
+	case payload := <-e.ack:
+		return payload, true
+	case <-time.After(d):
+		return nil, false
+	}
+}
+
+func channelType(channel string) string {
+	if len(channel) > 0 && channel[0] == 'D' {
+		return "im"
+	}
+
+	return "channel"
+}
@@ -0,0 +1,92 @@
+package hanutest
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ChrisMcKee/hanu"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/socketmode"
+)
+
+func TestServerRecordsCalls(t *testing.T) {
+	h := New(t)
+
+	if h.Bot.ID != BotUserID {
+		t.Errorf("Bot.ID should be %s, is %s", BotUserID, h.Bot.ID)
+	}
+
+	h.Server.Handle("users.info", func(call Call) interface{} {
+		return map[string]interface{}{
+			"ok":   true,
+			"user": map[string]interface{}{"id": call.Values.Get("user"), "real_name": "Turtle"},
+		}
+	})
+
+	user, err := h.Bot.SocketClient.GetUserInfo("U1")
+	if err != nil {
+		t.Fatalf("GetUserInfo failed: %v", err)
+	}
+
+	if user.RealName != "Turtle" {
+		t.Errorf("RealName should be \"Turtle\", is \"%s\"", user.RealName)
+	}
+
+	if calls := h.Server.Calls("users.info"); len(calls) != 1 || calls[0].Values.Get("user") != "U1" {
+		t.Errorf("users.info call was not recorded: %+v", calls)
+	}
+}
+
+func TestInteractionAck(t *testing.T) {
+	h := New(t)
+	h.Bot.RegisterInteraction(slack.InteractionTypeViewSubmission, func(evt *socketmode.Event, client *socketmode.Client) {
+		h.Bot.Ack(*evt.Request, map[string]string{"response_action": "clear"})
+	})
+
+	env := h.Interaction(slack.InteractionCallback{Type: slack.InteractionTypeViewSubmission})
+
+	var payload map[string]string
+	if err := json.Unmarshal(env.Ack(), &payload); err != nil {
+		t.Fatalf("ack payload is not JSON: %v", err)
+	}
+
+	if payload["response_action"] != "clear" {
+		t.Errorf("unexpected ack payload %+v", payload)
+	}
+}
+
+func TestUnackedInteraction(t *testing.T) {
+	h := New(t)
+	done := make(chan struct{})
+	defer close(done)
+	h.Bot.RegisterInteraction(slack.InteractionTypeViewSubmission, func(evt *socketmode.Event, client *socketmode.Client) {
+		<-done
+	})
+
+	env := h.Interaction(slack.InteractionCallback{Type: slack.InteractionTypeViewSubmission})
+	if _, ok := env.Acked(100 * time.Millisecond); ok {
+		t.Errorf("interaction whose handler is still running should not be acknowledged")
+	}
+}
+
+func TestPostsInOrder(t *testing.T) {
+	h := New(t)
+	h.Bot.Command("count", func(conv hanu.Convo) {
+		conv.Reply("one")
+	})
+
+	h.Message("U1", "D1", "count")
+	first := h.NextPost()
+
+	h.Message("U1", "D1", "count")
+	second := h.NextPost()
+
+	if first.Timestamp >= second.Timestamp {
+		t.Errorf("timestamps should increase: %s, %s", first.Timestamp, second.Timestamp)
+	}
+
+	if len(h.Server.Posts()) != 2 {
+		t.Errorf("two posts should be recorded, got %d", len(h.Server.Posts()))
+	}
+}
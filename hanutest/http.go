@@ -0,0 +1,29 @@
+package hanutest
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"time"
+)
+
+// NewSignedRequest builds a request to a bot's HTTPHandler signed with the
+// given signing secret, the way Slack signs requests
+func NewSignedRequest(signingSecret, contentType string, body []byte) *http.Request {
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(signingSecret))
+	mac.Write([]byte("v0:" + ts + ":"))
+	mac.Write(body)
+
+	r := httptest.NewRequest(http.MethodPost, "/slack", bytes.NewReader(body))
+	r.Header.Set("Content-Type", contentType)
+	r.Header.Set("X-Slack-Request-Timestamp", ts)
+	r.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+
+	return r
+}
@@ -0,0 +1,319 @@
+package hanutest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// BotUserID is the user ID the fake backend reports for the bot in auth.test
+const BotUserID = "UHANUBOT"
+
+// MethodHandler answers a Slack Web API method call with a JSON serialisable response
+type MethodHandler func(call Call) interface{}
+
+// Call is a recorded Slack Web API method call
+type Call struct {
+	Method string
+	Values url.Values
+	Body   []byte
+}
+
+// Post is a message the bot sent using chat.postMessage
+type Post struct {
+	Channel         string
+	Text            string
+	Timestamp       string
+	ThreadTimestamp string
+	Values          url.Values
+}
+
+// Server is a fake Slack backend serving the Web API and a Socket Mode websocket
+type Server struct {
+	httpServer *httptest.Server
+	upgrader   websocket.Upgrader
+
+	mu       sync.Mutex
+	handlers map[string]MethodHandler
+	calls    []Call
+	posts    []Post
+	postCh   chan Post
+	acks     map[string]chan json.RawMessage
+	conn     *websocket.Conn
+	connCh   chan struct{}
+	envelope int
+	ts       int
+	closed   chan struct{}
+}
+
+// NewServer starts a new fake Slack backend
+func NewServer() *Server {
+	s := &Server{
+		handlers: make(map[string]MethodHandler),
+		postCh:   make(chan Post, 100),
+		acks:     make(map[string]chan json.RawMessage),
+		connCh:   make(chan struct{}),
+		closed:   make(chan struct{}),
+	}
+	// socket mode clients always send https://api.slack.com as origin
+	s.upgrader.CheckOrigin = func(r *http.Request) bool { return true }
+
+	s.Handle("auth.test", func(call Call) interface{} {
+		return map[string]interface{}{
+			"ok":      true,
+			"user_id": BotUserID,
+			"user":    "hanu",
+			"team_id": "THANU",
+			"team":    "hanu",
+			"bot_id":  "BHANU",
+		}
+	})
+	s.Handle("apps.connections.open", func(call Call) interface{} {
+		return map[string]interface{}{
+			"ok":  true,
+			"url": "ws" + strings.TrimPrefix(s.httpServer.URL, "http") + "/ws",
+		}
+	})
+	s.Handle("chat.postMessage", func(call Call) interface{} {
+		post := s.recordPost(call)
+		return map[string]interface{}{
+			"ok":      true,
+			"channel": postedChannel(post.Channel),
+			"ts":      post.Timestamp,
+		}
+	})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/", s.serveAPI)
+	mux.HandleFunc("/ws", s.serveSocket)
+	s.httpServer = httptest.NewServer(mux)
+
+	return s
+}
+
+// postedChannel returns the channel Slack reports a message was posted to,
+// messages sent to a user ID are posted to the direct message channel with
+// the user, whose ID starts with D instead of U
+func postedChannel(channel string) string {
+	if strings.HasPrefix(channel, "U") {
+		return "D" + strings.TrimPrefix(channel, "U")
+	}
+
+	return channel
+}
+
+// APIURL returns the Web API endpoint to pass to slack.OptionAPIURL
+func (s *Server) APIURL() string {
+	return s.httpServer.URL + "/api/"
+}
+
+// Handle replaces the response for a Web API method; methods without a
+// handler answer with {"ok": true}
+func (s *Server) Handle(method string, h MethodHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.handlers[method] = h
+}
+
+// Calls returns all recorded calls of the given Web API method
+func (s *Server) Calls(method string) []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var calls []Call
+	for _, c := range s.calls {
+		if c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+
+	return calls
+}
+
+// Posts returns all messages posted so far
+func (s *Server) Posts() []Post {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Post(nil), s.posts...)
+}
+
+// Close shuts the fake backend down
+func (s *Server) Close() {
+	s.mu.Lock()
+	select {
+	case <-s.closed:
+	default:
+		close(s.closed)
+	}
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.httpServer.CloseClientConnections()
+	s.httpServer.Close()
+}
+
+func (s *Server) serveAPI(w http.ResponseWriter, r *http.Request) {
+	call := Call{Method: strings.TrimPrefix(r.URL.Path, "/api/")}
+
+	body, _ := io.ReadAll(r.Body)
+	call.Body = body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		call.Values, _ = url.ParseQuery(string(body))
+	} else {
+		call.Values = r.URL.Query()
+	}
+
+	s.mu.Lock()
+	s.calls = append(s.calls, call)
+	h, ok := s.handlers[call.Method]
+	s.mu.Unlock()
+
+	var resp interface{} = map[string]interface{}{"ok": true}
+	if ok {
+		resp = h(call)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (s *Server) recordPost(call Call) Post {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ts++
+	post := Post{
+		Channel:         call.Values.Get("channel"),
+		Text:            call.Values.Get("text"),
+		Timestamp:       fmt.Sprintf("1700000000.%06d", s.ts),
+		ThreadTimestamp: call.Values.Get("thread_ts"),
+		Values:          call.Values,
+	}
+	s.posts = append(s.posts, post)
+
+	select {
+	case s.postCh <- post:
+	default:
+	}
+
+	return post
+}
+
+func (s *Server) serveSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.conn = conn
+	first := s.connCh
+	s.mu.Unlock()
+
+	s.write(map[string]interface{}{"type": "hello", "num_connections": 1})
+
+	select {
+	case <-first:
+	default:
+		close(first)
+	}
+
+	go s.ping(conn)
+
+	for {
+		var res struct {
+			EnvelopeID string          `json:"envelope_id"`
+			Payload    json.RawMessage `json:"payload"`
+		}
+		if err := conn.ReadJSON(&res); err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		ch, ok := s.acks[res.EnvelopeID]
+		s.mu.Unlock()
+
+		if ok {
+			select {
+			case ch <- res.Payload:
+			default:
+			}
+		}
+	}
+}
+
+// ping keeps the socket mode client from timing out the connection
+func (s *Server) ping(conn *websocket.Conn) {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(time.Second)); err != nil {
+			return
+		}
+
+		select {
+		case <-ticker.C:
+		case <-s.closed:
+			return
+		}
+	}
+}
+
+// waitConnected blocks until the bot opened its socket mode connection
+func (s *Server) waitConnected(timeout time.Duration) bool {
+	select {
+	case <-s.connCh:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// deliver sends a socket mode request and returns the envelope ID and a
+// channel receiving the acknowledgement payload
+func (s *Server) deliver(typ string, payload interface{}) (string, chan json.RawMessage, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return "", nil, err
+	}
+
+	s.mu.Lock()
+	s.envelope++
+	id := fmt.Sprintf("envelope-%d", s.envelope)
+	ch := make(chan json.RawMessage, 1)
+	s.acks[id] = ch
+	s.mu.Unlock()
+
+	err = s.write(map[string]interface{}{
+		"type":                     typ,
+		"envelope_id":              id,
+		"payload":                  json.RawMessage(raw),
+		"accepts_response_payload": typ != "events_api",
+	})
+
+	return id, ch, err
+}
+
+func (s *Server) write(v interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return fmt.Errorf("bot is not connected")
+	}
+
+	return s.conn.WriteJSON(v)
+}
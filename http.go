@@ -0,0 +1,192 @@
+package hanu
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/slack-go/slack/socketmode"
+)
+
+// httpAckTimeout is how long an HTTP request waits for the handlers to
+// acknowledge it, Slack gives up after three seconds
+var httpAckTimeout = 2500 * time.Millisecond
+
+// httpMaxBodySize limits the size of the requests read before their
+// signature is verified
+const httpMaxBodySize = 1 << 20
+
+// httpHandler receives Events API events, interactions and slash commands
+// over HTTP
+type httpHandler struct {
+	bot           *Bot
+	signingSecret string
+	envelopes     uint64
+}
+
+// HTTPHandler returns a handler serving the bot over the HTTP Events API
+// instead of socket mode. It verifies request signatures with the app's
+// signing secret, answers url_verification challenges and dispatches events,
+// interactions and slash commands to the same handlers as Listen. It can be
+// mounted as request URL for all of them.
+func (b *Bot) HTTPHandler(signingSecret string) http.Handler {
+	b.listen()
+
+	return &httpHandler{bot: b, signingSecret: signingSecret}
+}
+
+func (h *httpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, httpMaxBodySize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	verifier, err := slack.NewSecretsVerifier(r.Header, h.signingSecret)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	verifier.Write(body)
+	if err := verifier.Ensure(); err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if h.bot.router.inflight.isStopped() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		h.serveEvent(w, body)
+		return
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case form.Get("ssl_check") != "":
+		// Slack checks the certificate of slash command URLs, nothing to dispatch
+		w.WriteHeader(http.StatusOK)
+	case form.Get("payload") != "":
+		h.serveInteraction(w, form.Get("payload"))
+	case form.Get("command") != "":
+		h.serveSlashCommand(w, r, body)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func (h *httpHandler) serveEvent(w http.ResponseWriter, body []byte) {
+	eventsAPIEvent, err := slackevents.ParseEvent(body, slackevents.OptionNoVerifyToken())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if eventsAPIEvent.Type == slackevents.URLVerification {
+		challenge, ok := eventsAPIEvent.Data.(*slackevents.EventsAPIURLVerificationEvent)
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(challenge.Challenge))
+		return
+	}
+
+	h.dispatch(w, socketmode.EventTypeEventsAPI, socketmode.RequestTypeEventsAPI, eventsAPIEvent, body)
+}
+
+func (h *httpHandler) serveInteraction(w http.ResponseWriter, payload string) {
+	var callback slack.InteractionCallback
+	if err := json.Unmarshal([]byte(payload), &callback); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	h.dispatch(w, socketmode.EventTypeInteractive, socketmode.RequestTypeInteractive, callback, []byte(payload))
+}
+
+func (h *httpHandler) serveSlashCommand(w http.ResponseWriter, r *http.Request, body []byte) {
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	cmd, err := slack.SlashCommandParse(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	payload, _ := json.Marshal(cmd)
+	h.dispatch(w, socketmode.EventTypeSlashCommand, socketmode.RequestTypeSlashCommands, cmd, payload)
+}
+
+// dispatch wraps the data in a socket mode event, so the handlers cannot
+// tell the transports apart, and writes the acknowledgement as response
+func (h *httpHandler) dispatch(w http.ResponseWriter, et socketmode.EventType, rt string, data interface{}, payload []byte) {
+	id := fmt.Sprintf("%s%d", httpEnvelopePrefix, atomic.AddUint64(&h.envelopes, 1))
+	evt := socketmode.Event{
+		Type: et,
+		Data: data,
+		Request: &socketmode.Request{
+			Type:       rt,
+			EnvelopeID: id,
+			Payload:    payload,
+		},
+	}
+
+	h.bot.metrics.eventReceived(evt)
+
+	ack, done := h.bot.acks.wait(id)
+	defer done()
+
+	// the request's context ends with the response, handlers often run longer.
+	// Handlers get a client of their own, acknowledgements sent with it cannot
+	// become the response and must not fill up the socket mode connection's
+	// queue, which nothing reads from over HTTP.
+	client := socketmode.New(&h.bot.SocketClient.Client)
+	if !h.bot.router.dispatch(context.Background(), evt, client) {
+		h.bot.log().Debug("unhandled event", "event_type", et)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	select {
+	case pld := <-ack:
+		if pld == nil {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(pld)
+	case <-time.After(httpAckTimeout):
+		h.bot.log().Warn("request was not acknowledged with Bot.Ack in time", "event_type", et)
+		w.WriteHeader(http.StatusOK)
+	}
+}
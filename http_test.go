@@ -0,0 +1,151 @@
+package hanu_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ChrisMcKee/hanu"
+	"github.com/ChrisMcKee/hanu/hanutest"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/socketmode"
+)
+
+const signingSecret = "8f742231b10e8888abcd99yyyzzz85a5"
+
+func serve(handler http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestHTTPRejectsInvalidSignature(t *testing.T) {
+	h := hanutest.New(t)
+	handler := h.Bot.HTTPHandler(signingSecret)
+
+	r := hanutest.NewSignedRequest("wrong secret", "application/json", []byte(`{"type":"url_verification","challenge":"abc"}`))
+	if w := serve(handler, r); w.Code != http.StatusUnauthorized {
+		t.Errorf("request with a wrong signature should be rejected, got %d", w.Code)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/slack", nil)
+	if w := serve(handler, r); w.Code != http.StatusUnauthorized {
+		t.Errorf("unsigned request should be rejected, got %d", w.Code)
+	}
+}
+
+func TestHTTPURLVerification(t *testing.T) {
+	h := hanutest.New(t)
+	handler := h.Bot.HTTPHandler(signingSecret)
+
+	r := hanutest.NewSignedRequest(signingSecret, "application/json", []byte(`{"token":"t","type":"url_verification","challenge":"3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P"}`))
+	w := serve(handler, r)
+
+	if w.Code != http.StatusOK || w.Body.String() != "3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P" {
+		t.Errorf("challenge should be echoed, got %d \"%s\"", w.Code, w.Body.String())
+	}
+}
+
+func TestHTTPMessageEvent(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.Command("ping", func(conv hanu.Convo) {
+		conv.Reply("pong")
+	})
+	handler := h.Bot.HTTPHandler(signingSecret)
+
+	body := `{"token":"t","team_id":"T1","api_app_id":"A1","type":"event_callback","event_id":"Ev1","event_time":1,` +
+		`"event":{"type":"message","user":"U1","text":"ping","ts":"1.1","channel":"D1","channel_type":"im"}}`
+	if w := serve(handler, hanutest.NewSignedRequest(signingSecret, "application/json", []byte(body))); w.Code != http.StatusOK {
+		t.Errorf("event should be accepted, got %d", w.Code)
+	}
+
+	if post := h.NextPost(); post.Channel != "D1" || post.Text != "pong" {
+		t.Errorf("bot should reply over the Web API, got \"%s\" in %s", post.Text, post.Channel)
+	}
+}
+
+func TestHTTPInteraction(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.RegisterInteraction(slack.InteractionTypeViewSubmission, func(evt *socketmode.Event, client *socketmode.Client) {
+		h.Bot.Ack(*evt.Request, map[string]string{"response_action": "clear"})
+	})
+	handler := h.Bot.HTTPHandler(signingSecret)
+
+	payload, _ := json.Marshal(slack.InteractionCallback{Type: slack.InteractionTypeViewSubmission})
+	form := url.Values{"payload": {string(payload)}}
+	w := serve(handler, hanutest.NewSignedRequest(signingSecret, "application/x-www-form-urlencoded", []byte(form.Encode())))
+
+	var ack map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &ack); err != nil || ack["response_action"] != "clear" {
+		t.Errorf("ack payload should be the response, got %d \"%s\"", w.Code, w.Body.String())
+	}
+}
+
+func TestHTTPSlashCommand(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.RegisterSlashCommand("/deploy", func(evt *socketmode.Event, client *socketmode.Client) {
+		cmd := evt.Data.(slack.SlashCommand)
+		h.Bot.Ack(*evt.Request, map[string]string{"text": "deploying " + cmd.Text})
+	})
+	handler := h.Bot.HTTPHandler(signingSecret)
+
+	form := url.Values{"command": {"/deploy"}, "text": {"api"}, "user_id": {"U1"}, "channel_id": {"C1"}}
+	w := serve(handler, hanutest.NewSignedRequest(signingSecret, "application/x-www-form-urlencoded", []byte(form.Encode())))
+
+	if w.Body.String() != "{\"text\":\"deploying api\"}\n" {
+		t.Errorf("slash command should be answered with the ack, got %d \"%s\"", w.Code, w.Body.String())
+	}
+}
+
+func TestHTTPSSLCheck(t *testing.T) {
+	h := hanutest.New(t)
+	handler := h.Bot.HTTPHandler(signingSecret)
+
+	form := url.Values{"ssl_check": {"1"}, "token": {"abc"}}
+	if w := serve(handler, hanutest.NewSignedRequest(signingSecret, "application/x-www-form-urlencoded", []byte(form.Encode()))); w.Code != http.StatusOK {
+		t.Errorf("SSL checks should be answered with 200, got %d", w.Code)
+	}
+}
+
+func TestHTTPUnhandled(t *testing.T) {
+	h := hanutest.New(t)
+	handler := h.Bot.HTTPHandler(signingSecret)
+
+	form := url.Values{"command": {"/unknown"}}
+	if w := serve(handler, hanutest.NewSignedRequest(signingSecret, "application/x-www-form-urlencoded", []byte(form.Encode()))); w.Code != http.StatusOK {
+		t.Errorf("unhandled requests should be acknowledged, got %d", w.Code)
+	}
+}
+
+func TestHTTPClientAcks(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.RegisterSlashCommand("/deploy", func(evt *socketmode.Event, client *socketmode.Client) {
+		// acknowledgements sent with the client cannot become the response
+		for i := 0; i < 3; i++ {
+			client.Ack(*evt.Request)
+		}
+		h.Bot.Ack(*evt.Request, map[string]string{"text": "deploying"})
+	})
+	handler := h.Bot.HTTPHandler(signingSecret)
+
+	form := url.Values{"command": {"/deploy"}}
+	for i := 0; i < 10; i++ {
+		w := serve(handler, hanutest.NewSignedRequest(signingSecret, "application/x-www-form-urlencoded", []byte(form.Encode())))
+		if w.Body.String() != "{\"text\":\"deploying\"}\n" {
+			t.Fatalf("request %d should be answered with the bot's ack, got %d \"%s\"", i, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestHTTPRejectsLargeRequests(t *testing.T) {
+	h := hanutest.New(t)
+	handler := h.Bot.HTTPHandler(signingSecret)
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(strings.Repeat("a", 2<<20)))
+	if w := serve(handler, r); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("large requests should be rejected before verifying them, got %d", w.Code)
+	}
+}
@@ -0,0 +1,268 @@
+package hanu
+
+import (
+	"context"
+	"log"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/socketmode"
+)
+
+// InteractionHandler handles an interaction routed by its callback, action
+// or block ID. Submissions and legacy message actions are acknowledged once
+// the handler returns unless the handler acknowledged them with a payload
+// itself, other interactions are acknowledged before the handler runs.
+type InteractionHandler func(b *Bot, cb slack.InteractionCallback, evt *socketmode.Event, client *socketmode.Client) error
+
+// interactionFunc handles a routed interaction, ctx is cancelled when the bot
+// stops listening or shuts down
+type interactionFunc func(ctx context.Context, cb slack.InteractionCallback, evt *socketmode.Event, client *socketmode.Client) error
+
+// withoutContext adapts an InteractionHandler to an interactionFunc
+func (h InteractionHandler) withoutContext(b *Bot) interactionFunc {
+	return func(ctx context.Context, cb slack.InteractionCallback, evt *socketmode.Event, client *socketmode.Client) error {
+		return h(b, cb, evt, client)
+	}
+}
+
+// callbackKey identifies the handler of a callback ID per interaction type
+type callbackKey struct {
+	typ slack.InteractionType
+	id  string
+}
+
+// callbackRoute is a handler registered for a callback ID, the callback IDs
+// of user scoped routes are prefixed with the ID of the interacting user
+type callbackRoute struct {
+	handler    interactionFunc
+	userScoped bool
+}
+
+// interactionRouter dispatches every interaction to a single handler, looked
+// up by callback ID, then action ID, then block ID
+type interactionRouter struct {
+	callbacks map[callbackKey]callbackRoute
+	actions   map[string]interactionFunc
+	blocks    map[string]interactionFunc
+	unknown   interactionFunc
+	acks      pendingAcks
+}
+
+// pendingAcks keeps track of the interactions being routed and whether they
+// were acknowledged yet
+type pendingAcks struct {
+	mu    sync.Mutex
+	acked map[string]bool
+}
+
+// track starts tracking an envelope
+func (p *pendingAcks) track(id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.acked == nil {
+		p.acked = make(map[string]bool)
+	}
+	p.acked[id] = false
+}
+
+// claim reports whether an envelope may be acknowledged, only the first
+// acknowledgement of a tracked envelope is sent
+func (p *pendingAcks) claim(id string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	acked, ok := p.acked[id]
+	if !ok {
+		return true
+	}
+
+	p.acked[id] = true
+	return !acked
+}
+
+// finish stops tracking an envelope and reports whether it still needs to be
+// acknowledged
+func (p *pendingAcks) finish(id string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	acked := p.acked[id]
+	delete(p.acked, id)
+	return !acked
+}
+
+// HandleCallback adds the handler of interactions of the given type with the
+// callback ID, the view's callback ID for view submissions
+func (b *Bot) HandleCallback(t slack.InteractionType, callbackID string, h InteractionHandler) {
+	b.handleCallback(t, callbackID, h.withoutContext(b), false)
+}
+
+func (b *Bot) handleCallback(t slack.InteractionType, callbackID string, h interactionFunc, userScoped bool) {
+	if b.listenerEnabled {
+		log.Fatal("HandleCallback must be called before Listen")
+	}
+
+	key := callbackKey{t, callbackID}
+	if b.interactions.callbacks == nil {
+		b.interactions.callbacks = make(map[callbackKey]callbackRoute)
+	}
+	if _, exist := b.interactions.callbacks[key]; exist {
+		panic("multiple registrations for callback " + callbackID)
+	}
+	b.interactions.callbacks[key] = callbackRoute{h, userScoped}
+}
+
+// HandleAction adds the handler of block actions with the given action ID,
+// e.g. a button's
+func (b *Bot) HandleAction(actionID string, h InteractionHandler) {
+	b.handleAction(actionID, h.withoutContext(b))
+}
+
+func (b *Bot) handleAction(actionID string, h interactionFunc) {
+	if b.listenerEnabled {
+		log.Fatal("HandleAction must be called before Listen")
+	}
+
+	if b.interactions.actions == nil {
+		b.interactions.actions = make(map[string]interactionFunc)
+	}
+	if _, exist := b.interactions.actions[actionID]; exist {
+		panic("multiple registrations for action " + actionID)
+	}
+	b.interactions.actions[actionID] = h
+}
+
+// HandleBlock adds the handler of block actions of elements in the block
+// with the given ID
+func (b *Bot) HandleBlock(blockID string, h InteractionHandler) {
+	b.handleBlock(blockID, h.withoutContext(b))
+}
+
+func (b *Bot) handleBlock(blockID string, h interactionFunc) {
+	if b.listenerEnabled {
+		log.Fatal("HandleBlock must be called before Listen")
+	}
+
+	if b.interactions.blocks == nil {
+		b.interactions.blocks = make(map[string]interactionFunc)
+	}
+	if _, exist := b.interactions.blocks[blockID]; exist {
+		panic("multiple registrations for block " + blockID)
+	}
+	b.interactions.blocks[blockID] = h
+}
+
+// HandleUnknownInteraction sets the handler of interactions no other handler
+// was added for. Without one they are only acknowledged.
+func (b *Bot) HandleUnknownInteraction(h InteractionHandler) {
+	b.interactions.unknown = h.withoutContext(b)
+}
+
+// lookup returns the handler of an interaction
+func (r *interactionRouter) lookup(cb slack.InteractionCallback) (interactionFunc, bool) {
+	id := cb.CallbackID
+	if cb.Type == slack.InteractionTypeViewSubmission || cb.Type == slack.InteractionTypeViewClosed {
+		id = cb.View.CallbackID
+	}
+
+	if route, ok := r.callbacks[callbackKey{cb.Type, id}]; ok && !route.userScoped {
+		return route.handler, true
+	}
+	if cb.User.ID != "" && strings.HasPrefix(id, cb.User.ID) {
+		if route, ok := r.callbacks[callbackKey{cb.Type, strings.TrimPrefix(id, cb.User.ID)}]; ok && route.userScoped {
+			return route.handler, true
+		}
+	}
+
+	for _, action := range cb.ActionCallback.BlockActions {
+		if h, ok := r.actions[action.ActionID]; ok {
+			return h, true
+		}
+	}
+	for _, action := range cb.ActionCallback.BlockActions {
+		if h, ok := r.blocks[action.BlockID]; ok {
+			return h, true
+		}
+	}
+
+	return r.unknown, r.unknown != nil
+}
+
+// middlewareInteraction adds the routed handler to the handlers registered
+// for the interaction's type and makes sure the interaction is acknowledged
+// once. It runs before any of them, so acknowledgements they send with Ack
+// are tracked, and acknowledges the interaction once they all returned
+// unless one of them did.
+func middlewareInteraction(b *Bot) func(evt *socketmode.Event, handlers []eventHandler) []eventHandler {
+	return func(evt *socketmode.Event, handlers []eventHandler) []eventHandler {
+		callback, ok := evt.Data.(slack.InteractionCallback)
+		if !ok || evt.Request == nil {
+			b.log().Debug("ignored event", "event_type", evt.Type)
+			return handlers
+		}
+
+		if h, ok := b.interactions.lookup(callback); ok {
+			handlers = append(handlers[:len(handlers):len(handlers)], func(ctx context.Context, evt *socketmode.Event, client *socketmode.Client) {
+				b.runInteractionHandler(ctx, h, callback, evt, client)
+			})
+		} else if len(handlers) == 0 {
+			b.log().Debug("unhandled interaction", "interaction_type", callback.Type, "callback_id", callback.CallbackID)
+			handlers = []eventHandler{func(ctx context.Context, evt *socketmode.Event, client *socketmode.Client) {}}
+		}
+
+		b.interactions.acks.track(evt.Request.EnvelopeID)
+
+		var running atomic.Int32
+		running.Store(int32(len(handlers)))
+
+		acked := make([]eventHandler, len(handlers))
+		for i, h := range handlers {
+			acked[i] = func(ctx context.Context, evt *socketmode.Event, client *socketmode.Client) {
+				defer func() {
+					if running.Add(-1) == 0 && b.interactions.acks.finish(evt.Request.EnvelopeID) {
+						b.Ack(*evt.Request)
+					}
+				}()
+
+				// Nothing can be sent with the acknowledgement, so handlers taking
+				// longer than Slack's three seconds don't fail the interaction
+				if !ackedWithPayload(callback.Type) {
+					b.Ack(*evt.Request)
+				}
+
+				h(ctx, evt, client)
+			}
+		}
+
+		return acked
+	}
+}
+
+// ackedWithPayload reports whether interactions of the type can be answered
+// with the acknowledgement's payload
+func ackedWithPayload(t slack.InteractionType) bool {
+	switch t {
+	case slack.InteractionTypeViewSubmission, slack.InteractionTypeDialogSubmission,
+		slack.InteractionTypeInteractionMessage, slack.InteractionTypeBlockSuggestion:
+		return true
+	}
+
+	return false
+}
+
+func (b *Bot) runInteractionHandler(ctx context.Context, h interactionFunc, callback slack.InteractionCallback, evt *socketmode.Event, client *socketmode.Client) {
+	defer func() {
+		if v := recover(); v != nil {
+			b.log().Error("interaction failed", interactionAttrs(evt, callback, &PanicError{Value: v, Stack: debug.Stack()})...)
+		}
+	}()
+
+	if err := h(ctx, callback, evt, client); err != nil {
+		b.log().Error("interaction failed", interactionAttrs(evt, callback, err)...)
+	}
+}
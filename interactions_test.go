@@ -0,0 +1,179 @@
+package hanu_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ChrisMcKee/hanu"
+	"github.com/ChrisMcKee/hanu/hanutest"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/socketmode"
+)
+
+// routed records which handler an interaction was routed to
+func routed(ch chan string, name string) hanu.InteractionHandler {
+	return func(b *hanu.Bot, cb slack.InteractionCallback, evt *socketmode.Event, client *socketmode.Client) error {
+		ch <- name
+		return nil
+	}
+}
+
+func blockAction(actionID, blockID string) slack.InteractionCallback {
+	return slack.InteractionCallback{
+		Type:           slack.InteractionTypeBlockActions,
+		User:           slack.User{ID: "U1"},
+		ActionCallback: slack.ActionCallbacks{BlockActions: []*slack.BlockAction{{ActionID: actionID, BlockID: blockID}}},
+	}
+}
+
+func TestInteractionRouting(t *testing.T) {
+	h := hanutest.New(t)
+	calls := make(chan string, 10)
+	h.Bot.HandleCallback(slack.InteractionTypeViewSubmission, "deploy", routed(calls, "deploy"))
+	h.Bot.HandleCallback(slack.InteractionTypeViewSubmission, "rollback", routed(calls, "rollback"))
+	h.Bot.HandleAction("approve", routed(calls, "approve"))
+	h.Bot.HandleBlock("deploy_actions", routed(calls, "deploy_actions"))
+	h.Bot.HandleUnknownInteraction(routed(calls, "unknown"))
+
+	data := []struct {
+		cb      slack.InteractionCallback
+		handler string
+	}{
+		{slack.InteractionCallback{Type: slack.InteractionTypeViewSubmission, View: slack.View{CallbackID: "rollback"}}, "rollback"},
+		{blockAction("approve", "deploy_actions"), "approve"},
+		{blockAction("cancel", "deploy_actions"), "deploy_actions"},
+		{blockAction("cancel", "other"), "unknown"},
+		{slack.InteractionCallback{Type: slack.InteractionTypeViewClosed, View: slack.View{CallbackID: "deploy"}}, "unknown"},
+	}
+
+	for _, d := range data {
+		h.Interaction(d.cb).Ack()
+
+		if handler := <-calls; handler != d.handler {
+			t.Errorf("interaction should be routed to %s, was routed to %s", d.handler, handler)
+		}
+	}
+
+	select {
+	case handler := <-calls:
+		t.Errorf("interactions should be routed to one handler, %s was called too", handler)
+	default:
+	}
+}
+
+func TestInteractionAckedOnce(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.HandleCallback(slack.InteractionTypeViewSubmission, "deploy", func(b *hanu.Bot, cb slack.InteractionCallback, evt *socketmode.Event, client *socketmode.Client) error {
+		b.Ack(*evt.Request, slack.NewClearViewSubmissionResponse())
+		b.Ack(*evt.Request)
+		return nil
+	})
+
+	env := h.Interaction(slack.InteractionCallback{Type: slack.InteractionTypeViewSubmission, View: slack.View{CallbackID: "deploy"}})
+
+	var payload map[string]string
+	if err := json.Unmarshal(env.Ack(), &payload); err != nil || payload["response_action"] != "clear" {
+		t.Errorf("the handler's acknowledgement should be sent, got %v (%v)", payload, err)
+	}
+
+	if _, ok := env.Acked(50 * time.Millisecond); ok {
+		t.Error("the interaction should only be acknowledged once")
+	}
+}
+
+func TestInteractionAckGuaranteed(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.HandleAction("fails", func(b *hanu.Bot, cb slack.InteractionCallback, evt *socketmode.Event, client *socketmode.Client) error {
+		return errors.New("failed")
+	})
+	h.Bot.HandleAction("panics", func(b *hanu.Bot, cb slack.InteractionCallback, evt *socketmode.Event, client *socketmode.Client) error {
+		panic("failed")
+	})
+
+	for _, cb := range []slack.InteractionCallback{blockAction("fails", ""), blockAction("panics", ""), blockAction("unknown", "")} {
+		if _, ok := h.Interaction(cb).Acked(hanutest.Timeout); !ok {
+			t.Errorf("%s should be acknowledged", cb.ActionCallback.BlockActions[0].ActionID)
+		}
+	}
+}
+
+func TestDialogRouting(t *testing.T) {
+	h := hanutest.New(t)
+	calls := make(chan string, 10)
+	for _, id := range []string{"deploy", "rollback"} {
+		h.Bot.RegisterModalInteraction(hanu.DialogCfg{
+			Type:              hanu.Modal,
+			CallbackId:        id,
+			Dialog:            hanu.DialogEvtHandler(routed(calls, id+" opened")),
+			SubmissionHandler: hanu.DialogEvtHandler(routed(calls, id+" submitted")),
+		})
+	}
+
+	h.Interaction(slack.InteractionCallback{Type: slack.InteractionTypeInteractionMessage, CallbackID: h.Bot.ID + "rollback"}).Ack()
+	h.Interaction(slack.InteractionCallback{Type: slack.InteractionTypeViewSubmission, User: slack.User{ID: "U1"}, View: slack.View{CallbackID: "U1deploy"}}).Ack()
+
+	for _, want := range []string{"rollback opened", "deploy submitted"} {
+		if got := <-calls; got != want {
+			t.Errorf("%s should be handled, got %s", want, got)
+		}
+	}
+}
+
+func TestUserScopedRoutesNeedUserPrefix(t *testing.T) {
+	h := hanutest.New(t)
+	calls := make(chan string, 2)
+	h.Bot.RegisterModalInteraction(hanu.DialogCfg{
+		Type:              hanu.Modal,
+		CallbackId:        "deploy",
+		SubmissionHandler: hanu.DialogEvtHandler(routed(calls, "deploy submitted")),
+	})
+
+	h.Interaction(slack.InteractionCallback{Type: slack.InteractionTypeViewSubmission, User: slack.User{ID: "U1"}, View: slack.View{CallbackID: "deploy"}}).Ack()
+	h.Interaction(slack.InteractionCallback{Type: slack.InteractionTypeViewSubmission, User: slack.User{ID: "U1"}, View: slack.View{CallbackID: "U2deploy"}}).Ack()
+
+	select {
+	case got := <-calls:
+		t.Errorf("views without the user's prefix should not be routed, got %s", got)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestRawHandlerAckIsNotRepeated(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.RegisterInteraction(slack.InteractionTypeViewSubmission, func(evt *socketmode.Event, client *socketmode.Client) {
+		h.Bot.Ack(*evt.Request, map[string]string{"text": "raw"})
+	})
+	h.Bot.HandleCallback(slack.InteractionTypeViewSubmission, "deploy", func(b *hanu.Bot, cb slack.InteractionCallback, evt *socketmode.Event, client *socketmode.Client) error {
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	})
+
+	env := h.Interaction(slack.InteractionCallback{Type: slack.InteractionTypeViewSubmission, View: slack.View{CallbackID: "deploy"}})
+	if payload := env.Ack(); string(payload) != `{"text":"raw"}` {
+		t.Errorf("raw handler's ack should be sent, got %s", payload)
+	}
+	if payload, ok := env.Acked(200 * time.Millisecond); ok {
+		t.Errorf("interaction should be acknowledged once, was acknowledged again with %s", payload)
+	}
+}
+
+func TestRawHandlerIgnoringInteractionIsAcknowledged(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.RegisterInteraction(slack.InteractionTypeViewSubmission, func(evt *socketmode.Event, client *socketmode.Client) {
+		cb := evt.Data.(slack.InteractionCallback)
+		switch cb.View.CallbackID {
+		case "rollback":
+			h.Bot.Ack(*evt.Request)
+		}
+	})
+
+	env := h.Interaction(slack.InteractionCallback{Type: slack.InteractionTypeViewSubmission, View: slack.View{CallbackID: "deploy"}})
+	if _, ok := env.Acked(hanutest.Timeout); !ok {
+		t.Error("interaction the raw handler ignored should be acknowledged")
+	}
+	if payload, ok := env.Acked(200 * time.Millisecond); ok {
+		t.Errorf("interaction should be acknowledged once, was acknowledged again with %s", payload)
+	}
+}
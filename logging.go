@@ -0,0 +1,32 @@
+package hanu
+
+import (
+	"log/slog"
+)
+
+// SetLogger will set the logger dispatched commands, failures and ignored
+// events are logged to, slog.Default() is used by default
+func (b *Bot) SetLogger(logger *slog.Logger) *Bot {
+	b.logger = logger
+	return b
+}
+
+// Logger returns the logger set with SetLogger or slog.Default(), so
+// handlers can log alongside the bot
+func (b *Bot) Logger() *slog.Logger {
+	return b.log()
+}
+
+// log returns the bot's logger
+func (b *Bot) log() *slog.Logger {
+	if b.logger == nil {
+		return slog.Default()
+	}
+
+	return b.logger
+}
+
+// messageAttrs returns the log attributes describing a message
+func messageAttrs(msg MessageInterface) []any {
+	return []any{"channel", msg.Channel(), "user", msg.User()}
+}
@@ -0,0 +1,103 @@
+package hanu_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ChrisMcKee/hanu"
+	"github.com/ChrisMcKee/hanu/hanutest"
+)
+
+// recorder is a slog.Handler remembering the records it handled
+type recorder struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (r *recorder) Enabled(context.Context, slog.Level) bool { return true }
+func (r *recorder) WithAttrs([]slog.Attr) slog.Handler       { return r }
+func (r *recorder) WithGroup(string) slog.Handler            { return r }
+
+func (r *recorder) Handle(_ context.Context, record slog.Record) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.records = append(r.records, record)
+	return nil
+}
+
+// find returns the attributes of the first record with the given message
+func (r *recorder) find(msg string) (map[string]string, bool) {
+	deadline := time.Now().Add(hanutest.Timeout)
+	for time.Now().Before(deadline) {
+		r.mu.Lock()
+		for _, record := range r.records {
+			if record.Message != msg {
+				continue
+			}
+
+			attrs := make(map[string]string)
+			record.Attrs(func(a slog.Attr) bool {
+				attrs[a.Key] = a.Value.String()
+				return true
+			})
+			r.mu.Unlock()
+			return attrs, true
+		}
+		r.mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return nil, false
+}
+
+func TestLogDispatchAndFailure(t *testing.T) {
+	h := hanutest.New(t)
+	rec := &recorder{}
+	h.Bot.SetLogger(slog.New(rec))
+	h.Bot.CommandE("deploy <service>", func(conv hanu.Convo) error {
+		return errors.New("boom")
+	})
+
+	h.Message("U1", "C1", "deploy api")
+
+	attrs, ok := rec.find("dispatching command")
+	if !ok {
+		t.Fatalf("dispatch should be logged")
+	}
+	if attrs["channel"] != "C1" || attrs["user"] != "U1" || attrs["command"] != "deploy <service>" {
+		t.Errorf("dispatch record should describe the request, got %v", attrs)
+	}
+
+	attrs, ok = rec.find("command failed")
+	if !ok {
+		t.Fatalf("failure should be logged")
+	}
+	if attrs["error"] != "boom" || attrs["channel"] != "C1" || attrs["user"] != "U1" {
+		t.Errorf("failure record should describe the error, got %v", attrs)
+	}
+}
+
+func TestLogSendFailure(t *testing.T) {
+	h := hanutest.New(t)
+	rec := &recorder{}
+	h.Bot.SetLogger(slog.New(rec))
+	h.Server.Handle("chat.postMessage", func(call hanutest.Call) interface{} {
+		return map[string]interface{}{"ok": false, "error": "channel_not_found"}
+	})
+
+	h.Bot.Say("C404", "hello")
+
+	attrs, ok := rec.find("failed posting message")
+	if !ok {
+		t.Fatalf("send failure should be logged")
+	}
+	if attrs["channel"] != "C404" || attrs["error"] != "channel_not_found" {
+		t.Errorf("send failure record should describe the error, got %v", attrs)
+	}
+}
@@ -13,12 +13,16 @@ type MessageInterface interface {
 	IsFrom(user string) bool
 	IsHelpRequest() bool
 	IsDirectMessage() bool
+	IsThreaded() bool
 	IsMentionFor(user string) bool
 	IsRelevantFor(user string) bool
 
 	Text() string
 	User() string
 	Channel() string
+	Timestamp() string
+	ThreadTimestamp() string
+	Files() []File
 }
 
 func NewMessage(ev *slackevents.MessageEvent) Message {
@@ -28,6 +32,11 @@ func NewMessage(ev *slackevents.MessageEvent) Message {
 	msg.OriginalMessage = ev.Text
 	msg.UserID = ev.User
 	msg.Type = ev.Type
+	msg.TimeStamp = ev.TimeStamp
+	msg.ThreadTimeStamp = ev.ThreadTimeStamp
+	if ev.Message != nil {
+		msg.FileList = newFiles(ev.Message.Files)
+	}
 	return msg
 }
 
@@ -38,6 +47,8 @@ func NewMentionMessage(ev *slackevents.AppMentionEvent) Message {
 	msg.OriginalMessage = ev.Text
 	msg.UserID = ev.User
 	msg.Type = ev.Type
+	msg.TimeStamp = ev.TimeStamp
+	msg.ThreadTimeStamp = ev.ThreadTimeStamp
 	return msg
 }
 
@@ -49,6 +60,9 @@ type Message struct {
 	UserID          string
 	Message         string
 	OriginalMessage string
+	TimeStamp       string
+	ThreadTimeStamp string
+	FileList        []File
 }
 
 // Text returns the message text
@@ -66,6 +80,27 @@ func (m Message) User() string {
 	return m.UserID
 }
 
+// Timestamp returns the timestamp identifying the message
+func (m Message) Timestamp() string {
+	return m.TimeStamp
+}
+
+// ThreadTimestamp returns the timestamp of the thread's parent message,
+// it is empty if the message was not posted in a thread
+func (m Message) ThreadTimestamp() string {
+	return m.ThreadTimeStamp
+}
+
+// Files returns the files attached to the message
+func (m Message) Files() []File {
+	return m.FileList
+}
+
+// IsThreaded checks if the message was posted in a thread
+func (m Message) IsThreaded() bool {
+	return m.ThreadTimeStamp != ""
+}
+
 // IsMessage checks if it is a Message or some other kind of processing information
 func (m Message) IsMessage() bool {
 	return true
@@ -1,6 +1,10 @@
 package hanu
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/slack-go/slack/slackevents"
+)
 
 func TestMessage(t *testing.T) {
 	msg := Message{
@@ -84,3 +88,31 @@ func TestStripFormatting(t *testing.T) {
 		}
 	}
 }
+
+func TestNewMessage(t *testing.T) {
+	msg := NewMessage(&slackevents.MessageEvent{
+		Type:            "message",
+		User:            "U1",
+		Channel:         "C1",
+		Text:            "text",
+		TimeStamp:       "1.2",
+		ThreadTimeStamp: "1.1",
+	})
+
+	if msg.Timestamp() != "1.2" {
+		t.Errorf("msg.Timestamp() must be \"1.2\", is \"%s\"", msg.Timestamp())
+	}
+
+	if msg.ThreadTimestamp() != "1.1" || !msg.IsThreaded() {
+		t.Errorf("msg must be threaded in \"1.1\", is \"%s\"", msg.ThreadTimestamp())
+	}
+
+	mention := NewMentionMessage(&slackevents.AppMentionEvent{
+		Type:      "app_mention",
+		TimeStamp: "1.3",
+	})
+
+	if mention.Timestamp() != "1.3" || mention.IsThreaded() {
+		t.Errorf("mention must not be threaded")
+	}
+}
@@ -0,0 +1,270 @@
+package hanu
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/slack-go/slack/socketmode"
+)
+
+// durationBuckets are the upper bounds of the handler latency histogram in
+// seconds, the same as Prometheus' default buckets
+var durationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Metrics counts what the bot does and serves the counts in the Prometheus
+// text exposition format, it is an http.Handler
+type Metrics struct {
+	mu       sync.Mutex
+	families []*metricFamily
+
+	events            *metricFamily
+	commandsMatched   *metricFamily
+	commandsUnmatched *metricFamily
+	handlerDuration   *metricFamily
+	handlerErrors     *metricFamily
+	sendFailures      *metricFamily
+	socketEvents      *metricFamily
+}
+
+// metricFamily is a counter or histogram with its series by label values
+type metricFamily struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+	series map[string]*metricSeries
+}
+
+// metricSeries is the value of a metric for one set of label values
+type metricSeries struct {
+	values  []string
+	value   float64
+	buckets []uint64
+	count   uint64
+}
+
+// NewMetrics creates the bot's metrics, pass them to SetMetrics
+func NewMetrics() *Metrics {
+	m := &Metrics{}
+	m.events = m.family("hanu_events_received_total", "Events received by type.", "counter", "type", "event")
+	m.commandsMatched = m.family("hanu_commands_matched_total", "Messages that matched a command.", "counter", "command")
+	m.commandsUnmatched = m.family("hanu_commands_unmatched_total", "Messages that did not match any command.", "counter")
+	m.handlerDuration = m.family("hanu_handler_duration_seconds", "Time command handlers took.", "histogram", "command")
+	m.handlerErrors = m.family("hanu_handler_errors_total", "Command handlers that returned an error or panicked.", "counter", "command")
+	m.sendFailures = m.family("hanu_send_failures_total", "Messages Slack failed to post.", "counter")
+	m.socketEvents = m.family("hanu_socket_connection_events_total", "Socket mode connection attempts, errors and established connections.", "counter", "state")
+
+	return m
+}
+
+func (m *Metrics) family(name string, help string, kind string, labels ...string) *metricFamily {
+	f := &metricFamily{
+		name:   name,
+		help:   help,
+		kind:   kind,
+		labels: labels,
+		series: make(map[string]*metricSeries),
+	}
+	m.families = append(m.families, f)
+
+	return f
+}
+
+// SetMetrics will make the bot count its activity, there are no metrics by
+// default
+func (b *Bot) SetMetrics(m *Metrics) *Bot {
+	b.metrics = m
+	return b
+}
+
+// eventReceived counts an event by its type and the type of its payload
+func (m *Metrics) eventReceived(evt socketmode.Event) {
+	if m == nil {
+		return
+	}
+
+	event := ""
+	switch data := evt.Data.(type) {
+	case slackevents.EventsAPIEvent:
+		event = data.InnerEvent.Type
+	case slack.InteractionCallback:
+		event = string(data.Type)
+	case slack.SlashCommand:
+		event = data.Command
+	}
+
+	m.inc(m.events, string(evt.Type), event)
+}
+
+// commandMatched counts a message that matched the command
+func (m *Metrics) commandMatched(cmd CommandInterface) {
+	if m == nil {
+		return
+	}
+
+	m.inc(m.commandsMatched, cmd.Get().Text())
+}
+
+// commandUnmatched counts a message that did not match any command
+func (m *Metrics) commandUnmatched() {
+	if m == nil {
+		return
+	}
+
+	m.inc(m.commandsUnmatched)
+}
+
+// handled records how long the handler of a command took, the unknown
+// command handler is not measured
+func (m *Metrics) handled(command string, d time.Duration) {
+	if m == nil || command == "" {
+		return
+	}
+
+	m.observe(m.handlerDuration, d, command)
+}
+
+// handlerFailed counts a handler that returned an error or panicked
+func (m *Metrics) handlerFailed(command string) {
+	if m == nil || command == "" {
+		return
+	}
+
+	m.inc(m.handlerErrors, command)
+}
+
+// sendFailed counts a message that could not be posted
+func (m *Metrics) sendFailed() {
+	if m == nil {
+		return
+	}
+
+	m.inc(m.sendFailures)
+}
+
+// socketEvent counts a change of the socket mode connection
+func (m *Metrics) socketEvent(state string) {
+	if m == nil {
+		return
+	}
+
+	m.inc(m.socketEvents, state)
+}
+
+// inc adds one to a counter
+func (m *Metrics) inc(f *metricFamily, values ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f.get(values).value++
+}
+
+// observe adds a duration to a histogram
+func (m *Metrics) observe(f *metricFamily, d time.Duration, values ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := f.get(values)
+	if s.buckets == nil {
+		s.buckets = make([]uint64, len(durationBuckets))
+	}
+
+	v := d.Seconds()
+	for i, le := range durationBuckets {
+		if v <= le {
+			s.buckets[i]++
+		}
+	}
+	s.value += v
+	s.count++
+}
+
+func (f *metricFamily) get(values []string) *metricSeries {
+	key := strings.Join(values, "\xff")
+
+	s, ok := f.series[key]
+	if !ok {
+		s = &metricSeries{values: values}
+		f.series[key] = s
+	}
+
+	return s
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write([]byte(m.String()))
+}
+
+// String returns the metrics in the Prometheus text exposition format
+func (m *Metrics) String() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var sb strings.Builder
+	for _, f := range m.families {
+		fmt.Fprintf(&sb, "# HELP %s %s\n", f.name, f.help)
+		fmt.Fprintf(&sb, "# TYPE %s %s\n", f.name, f.kind)
+
+		keys := make([]string, 0, len(f.series))
+		for key := range f.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			s := f.series[key]
+			if f.kind != "histogram" {
+				fmt.Fprintf(&sb, "%s%s %s\n", f.name, labels(f.labels, s.values), formatFloat(s.value))
+				continue
+			}
+
+			bucketLabels := with(f.labels, "le")
+			for i, le := range durationBuckets {
+				fmt.Fprintf(&sb, "%s_bucket%s %d\n", f.name, labels(bucketLabels, with(s.values, formatFloat(le))), s.buckets[i])
+			}
+			fmt.Fprintf(&sb, "%s_bucket%s %d\n", f.name, labels(bucketLabels, with(s.values, "+Inf")), s.count)
+			fmt.Fprintf(&sb, "%s_sum%s %s\n", f.name, labels(f.labels, s.values), formatFloat(s.value))
+			fmt.Fprintf(&sb, "%s_count%s %d\n", f.name, labels(f.labels, s.values), s.count)
+		}
+	}
+
+	return sb.String()
+}
+
+// labels formats label names and values as {name="value",...}
+func labels(names []string, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+		pairs[i] = name + `="` + labelEscaper.Replace(value) + `"`
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// with returns a copy of list with s appended
+func with(list []string, s string) []string {
+	return append(append([]string(nil), list...), s)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,87 @@
+package hanu_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ChrisMcKee/hanu"
+	"github.com/ChrisMcKee/hanu/hanutest"
+)
+
+func TestMetrics(t *testing.T) {
+	metrics := hanu.NewMetrics()
+
+	h := hanutest.New(t)
+	h.Bot.SetMetrics(metrics)
+	h.Bot.Command("ping", func(conv hanu.Convo) {
+		conv.Reply("pong")
+	})
+	h.Bot.CommandE("fail", func(conv hanu.Convo) error {
+		conv.Reply("failing")
+		return errors.New("boom")
+	})
+
+	h.Message("U1", "D1", "ping")
+	h.NextPost()
+	h.Message("U1", "D1", "fail")
+	h.NextPost()
+	h.Message("U1", "C1", "just chatting")
+	h.NoPost(100 * time.Millisecond)
+
+	server := httptest.NewServer(metrics)
+	defer server.Close()
+
+	res, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("metrics should be served: %v", err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+
+	if !strings.HasPrefix(res.Header.Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Errorf("metrics should use the Prometheus text format, got %s", res.Header.Get("Content-Type"))
+	}
+
+	for _, line := range []string{
+		"# TYPE hanu_events_received_total counter",
+		`hanu_events_received_total{type="events_api",event="message"} 3`,
+		`hanu_commands_matched_total{command="ping"} 1`,
+		`hanu_commands_unmatched_total 1`,
+		"# TYPE hanu_handler_duration_seconds histogram",
+		`hanu_handler_duration_seconds_bucket{command="ping",le="+Inf"} 1`,
+		`hanu_handler_duration_seconds_count{command="fail"} 1`,
+		`hanu_handler_errors_total{command="fail"} 1`,
+		`hanu_socket_connection_events_total{state="connected"} 1`,
+	} {
+		if !strings.Contains(string(body), line+"\n") {
+			t.Errorf("metrics should contain %s, got:\n%s", line, body)
+		}
+	}
+}
+
+func TestMetricsSendFailures(t *testing.T) {
+	metrics := hanu.NewMetrics()
+
+	h := hanutest.New(t)
+	h.Bot.SetMetrics(metrics)
+	h.Server.Handle("chat.postMessage", func(call hanutest.Call) interface{} {
+		return map[string]interface{}{"ok": false, "error": "channel_not_found"}
+	})
+
+	failed := make(chan struct{})
+	h.Bot.SetSendFailureHandler(func(msg hanu.MessageInterface, err error) {
+		close(failed)
+	})
+
+	h.Bot.Say("C404", "hello")
+	<-failed
+
+	if !strings.Contains(metrics.String(), "hanu_send_failures_total 1\n") {
+		t.Errorf("send failures should be counted, got:\n%s", metrics.String())
+	}
+}
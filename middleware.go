@@ -0,0 +1,56 @@
+package hanu
+
+// Middleware wraps a Handler, e.g. to log, time or authorize commands.
+// It can short-circuit the command by not calling the wrapped handler.
+type Middleware func(Handler) Handler
+
+// Use adds middleware around every command and the unknown command handler,
+// the first middleware added is the outermost one
+func (b *Bot) Use(mw ...Middleware) *Bot {
+	b.middleware = append(b.middleware, mw...)
+	return b
+}
+
+// wrap applies the bot's middleware to a handler
+func (b *Bot) wrap(h Handler) Handler {
+	for i := len(b.middleware) - 1; i >= 0; i-- {
+		h = b.middleware[i](h)
+	}
+
+	return h
+}
+
+// commandHandler adapts a command to a Handler, it denies users without
+// permission and reports errors checking permissions and errors returned by
+// commands implementing HandleE
+func (b *Bot) commandHandler(cmd CommandInterface) Handler {
+	return func(conv Convo) {
+		allowed, err := b.IsAllowed(conv.Context(), cmd, conv.Message())
+		if err != nil {
+			b.fail(conv, err)
+			return
+		}
+
+		if !allowed {
+			b.log().Info("command denied", append(messageAttrs(conv.Message()), "command", cmd.Get().Text())...)
+			markFailed(conv)
+			if b.denyReply != "" {
+				conv.Reply("%s", b.denyReply)
+			}
+			return
+		}
+
+		c, ok := cmd.(interface {
+			HandleE(conv ConversationInterface) error
+		})
+		if !ok {
+			cmd.Handle(conv)
+			return
+		}
+
+		if err := c.HandleE(conv); err != nil {
+			b.metrics.handlerFailed(cmd.Get().Text())
+			b.fail(conv, err)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package hanu_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ChrisMcKee/hanu"
+	"github.com/ChrisMcKee/hanu/hanutest"
+)
+
+func trace(calls *[]string, name string) hanu.Middleware {
+	return func(next hanu.Handler) hanu.Handler {
+		return func(conv hanu.Convo) {
+			*calls = append(*calls, name+" before")
+			next(conv)
+			*calls = append(*calls, name+" after")
+		}
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	var calls []string
+	done := make(chan struct{})
+
+	h := hanutest.New(t)
+	h.Bot.Use(func(next hanu.Handler) hanu.Handler {
+		return func(conv hanu.Convo) {
+			next(conv)
+			close(done)
+		}
+	})
+	h.Bot.Use(trace(&calls, "outer"), trace(&calls, "inner"))
+	h.Bot.Register(hanu.NewCommand("ping", "", func(conv hanu.Convo) {
+		calls = append(calls, "handler")
+	}))
+
+	h.Message("U1", "D1", "ping")
+
+	select {
+	case <-done:
+	case <-time.After(hanutest.Timeout):
+		t.Fatalf("command was not handled")
+	}
+
+	expected := "outer before, inner before, handler, inner after, outer after"
+	if strings.Join(calls, ", ") != expected {
+		t.Errorf("middleware should be called as \"%s\", was \"%s\"", expected, strings.Join(calls, ", "))
+	}
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.Use(func(next hanu.Handler) hanu.Handler {
+		return func(conv hanu.Convo) {
+			if conv.Message().User() != "UADMIN" {
+				conv.Reply("not allowed")
+				return
+			}
+			next(conv)
+		}
+	})
+	h.Bot.Command("deploy", func(conv hanu.Convo) {
+		conv.Reply("deploying")
+	})
+
+	h.Message("U1", "D1", "deploy")
+	if post := h.NextPost(); post.Text != "not allowed" {
+		t.Errorf("middleware should stop the command, got \"%s\"", post.Text)
+	}
+
+	h.Message("UADMIN", "D1", "deploy")
+	if post := h.NextPost(); post.Text != "deploying" {
+		t.Errorf("middleware should run the command, got \"%s\"", post.Text)
+	}
+}
+
+func TestMiddlewareUnknownCommand(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.SetReplyOnly(true).UnknownCommand(func(conv hanu.Convo) {
+		conv.Reply("unknown")
+	})
+	h.Bot.Use(func(next hanu.Handler) hanu.Handler {
+		return func(conv hanu.Convo) {
+			conv.Reply("wrapped")
+			next(conv)
+		}
+	})
+
+	h.Message("U1", "D1", "nope")
+
+	if post := h.NextPost(); post.Text != "wrapped" {
+		t.Errorf("middleware should wrap the unknown command handler, got \"%s\"", post.Text)
+	}
+
+	if post := h.NextPost(); post.Text != "unknown" {
+		t.Errorf("unknown command handler should run, got \"%s\"", post.Text)
+	}
+}
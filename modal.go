@@ -0,0 +1,234 @@
+package hanu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/socketmode"
+)
+
+// errUnsupportedField is returned when decoding into a field of an
+// unsupported type
+var errUnsupportedField = errors.New("hanu: cannot decode view state into field")
+
+// ViewErrors are validation errors of a submitted view keyed by block ID,
+// returned by a submission handler they are shown next to the inputs
+type ViewErrors map[string]string
+
+// Error lists the validation errors
+func (e ViewErrors) Error() string {
+	blocks := make([]string, 0, len(e))
+	for block := range e {
+		blocks = append(blocks, block)
+	}
+	sort.Strings(blocks)
+
+	msgs := make([]string, len(blocks))
+	for i, block := range blocks {
+		msgs[i] = block + ": " + e[block]
+	}
+
+	return "invalid view submission: " + strings.Join(msgs, ", ")
+}
+
+// ViewSubmission is a submitted modal view
+type ViewSubmission struct {
+	// User is the user who submitted the view
+	User string
+	// TriggerID allows opening another modal in response to the submission
+	TriggerID string
+
+	callback slack.InteractionCallback
+	response *slack.ViewSubmissionResponse
+	ctx      context.Context
+}
+
+// SubmitHandler handles a view submission, the submission is acknowledged
+// with the response action set once it returns. Returning ViewErrors keeps the
+// view open and shows the errors.
+type SubmitHandler func(s *ViewSubmission) error
+
+// OnSubmit adds the handler of submissions of views with the given callback ID
+func (b *Bot) OnSubmit(callbackID string, h SubmitHandler) {
+	b.handleCallback(slack.InteractionTypeViewSubmission, callbackID, func(ctx context.Context, cb slack.InteractionCallback, evt *socketmode.Event, client *socketmode.Client) error {
+		s := &ViewSubmission{User: cb.User.ID, TriggerID: cb.TriggerID, callback: cb, ctx: ctx}
+
+		err := h(s)
+
+		var viewErrs ViewErrors
+		if errors.As(err, &viewErrs) {
+			b.Ack(*evt.Request, slack.NewErrorsViewSubmissionResponse(viewErrs))
+			return nil
+		}
+
+		if s.response != nil {
+			b.Ack(*evt.Request, s.response)
+		}
+
+		return err
+	}, false)
+}
+
+// Context returns the context of the interaction, it is cancelled when the
+// bot stops listening or shuts down
+func (s *ViewSubmission) Context() context.Context {
+	return s.ctx
+}
+
+// View returns the submitted view
+func (s *ViewSubmission) View() *slack.View {
+	return &s.callback.View
+}
+
+// Callback returns the interaction as received from Slack
+func (s *ViewSubmission) Callback() slack.InteractionCallback {
+	return s.callback
+}
+
+// Metadata decodes the private metadata of the submitted view into v
+func (s *ViewSubmission) Metadata(v interface{}) error {
+	return PrivateMetadata(s.View(), v)
+}
+
+// Update replaces the submitted view once the handler returns
+func (s *ViewSubmission) Update(view slack.ModalViewRequest) {
+	view = keepMetadata(s.View(), view)
+	s.response = slack.NewUpdateViewSubmissionResponse(&view)
+}
+
+// Push shows another view on top of the submitted one once the handler
+// returns
+func (s *ViewSubmission) Push(view slack.ModalViewRequest) {
+	view = keepMetadata(s.View(), view)
+	s.response = slack.NewPushViewSubmissionResponse(&view)
+}
+
+// Clear closes all views of the modal once the handler returns, by default
+// only the submitted one is closed
+func (s *ViewSubmission) Clear() {
+	s.response = slack.NewClearViewSubmissionResponse()
+}
+
+// Value returns the value of the input with the given block and action ID,
+// the action ID can be empty if the block has one input only
+func (s *ViewSubmission) Value(blockID, actionID string) string {
+	action, ok := s.input(blockID, actionID)
+	if !ok {
+		return ""
+	}
+
+	return actionValue(action)
+}
+
+// input returns the state of an input of the submitted view
+func (s *ViewSubmission) input(blockID, actionID string) (*slack.BlockAction, bool) {
+	if s.callback.View.State == nil {
+		return nil, false
+	}
+
+	actions := s.callback.View.State.Values[blockID]
+	if actionID == "" && len(actions) == 1 {
+		for _, action := range actions {
+			return &action, true
+		}
+	}
+
+	action, ok := actions[actionID]
+	return &action, ok
+}
+
+// Decode stores the submitted values in the struct v points to. Fields are
+// tagged with the block ID and optionally the action ID of their input, e.g.
+//
+//	Env       string   `hanu:"env_block"`
+//	Replicas  int      `hanu:"scale_block.replicas"`
+//	Reviewers []string `hanu:"reviewers_block"`
+//
+// Strings, integers, floats, bools (true if anything was selected) and
+// string slices are supported. Values that cannot be converted are returned
+// as ViewErrors.
+func (s *ViewSubmission) Decode(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("hanu: Decode needs a pointer to a struct, got %T", v)
+	}
+	rv = rv.Elem()
+
+	errs := ViewErrors{}
+	for i := 0; i < rv.NumField(); i++ {
+		field := rv.Type().Field(i)
+		tag, ok := field.Tag.Lookup("hanu")
+		if !ok || !field.IsExported() {
+			continue
+		}
+
+		blockID, actionID, _ := strings.Cut(tag, ".")
+		action, ok := s.input(blockID, actionID)
+		if !ok {
+			continue
+		}
+
+		err := decodeValue(rv.Field(i), action)
+		if errors.Is(err, errUnsupportedField) {
+			return fmt.Errorf("%w %s of type %s", err, field.Name, field.Type)
+		}
+		if err != nil {
+			errs[blockID] = err.Error()
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// decodeValue converts the value of an input to the type of the field
+func decodeValue(field reflect.Value, action *slack.BlockAction) error {
+	value := actionValue(action)
+	values := actionValues(action)
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		field.SetBool(value != "" || len(values) > 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if value == "" {
+			return nil
+		}
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%s is not a whole number", value)
+		}
+		field.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		if value == "" {
+			return nil
+		}
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%s is not a number", value)
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return errUnsupportedField
+		}
+		if len(values) == 0 && value != "" {
+			values = []string{value}
+		}
+		field.Set(reflect.ValueOf(values).Convert(field.Type()))
+	default:
+		return errUnsupportedField
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package hanu_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/ChrisMcKee/hanu"
+	"github.com/ChrisMcKee/hanu/hanutest"
+	"github.com/slack-go/slack"
+)
+
+type deployForm struct {
+	Service   string   `hanu:"service"`
+	Replicas  int      `hanu:"scale.replicas"`
+	Reviewers []string `hanu:"reviewers"`
+	Notify    bool     `hanu:"options"`
+	Comment   string   `hanu:"comment"`
+	ignored   string
+}
+
+func submission(callbackID string, values map[string]map[string]slack.BlockAction) slack.InteractionCallback {
+	return slack.InteractionCallback{
+		Type: slack.InteractionTypeViewSubmission,
+		User: slack.User{ID: "U1"},
+		View: slack.View{ID: "V1", CallbackID: callbackID, State: &slack.ViewState{Values: values}},
+	}
+}
+
+func deployValues(replicas string) map[string]map[string]slack.BlockAction {
+	return map[string]map[string]slack.BlockAction{
+		"service":   {"service_input": {Value: "api"}},
+		"scale":     {"replicas": {Value: replicas}, "zone": {SelectedOption: slack.OptionBlockObject{Value: "eu"}}},
+		"reviewers": {"reviewers_select": {SelectedUsers: []string{"U2", "U3"}}},
+		"options":   {"notify": {SelectedOptions: []slack.OptionBlockObject{{Value: "notify"}}}},
+	}
+}
+
+// submitAck submits a view and returns the response it was acknowledged with
+func submitAck(t *testing.T, h *hanutest.Harness, cb slack.InteractionCallback) slack.ViewSubmissionResponse {
+	t.Helper()
+
+	var resp slack.ViewSubmissionResponse
+	if payload := h.Interaction(cb).Ack(); len(payload) > 0 && string(payload) != "null" {
+		if err := json.Unmarshal(payload, &resp); err != nil {
+			t.Fatalf("acknowledgement should be a view submission response: %v", err)
+		}
+	}
+
+	return resp
+}
+
+func TestOnSubmitDecode(t *testing.T) {
+	h := hanutest.New(t)
+	forms := make(chan deployForm, 1)
+	h.Bot.OnSubmit("deploy", func(s *hanu.ViewSubmission) error {
+		var form deployForm
+		if err := s.Decode(&form); err != nil {
+			return err
+		}
+		if s.Value("scale", "zone") != "eu" {
+			t.Errorf("zone should be eu, is %s", s.Value("scale", "zone"))
+		}
+
+		forms <- form
+		return nil
+	})
+
+	if resp := submitAck(t, h, submission("deploy", deployValues("3"))); resp.ResponseAction != "" {
+		t.Errorf("successful submission should close the view, got %+v", resp)
+	}
+
+	want := deployForm{Service: "api", Replicas: 3, Reviewers: []string{"U2", "U3"}, Notify: true}
+	if form := <-forms; !reflect.DeepEqual(form, want) {
+		t.Errorf("submission should be decoded to %+v, got %+v", want, form)
+	}
+}
+
+func TestOnSubmitErrors(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.OnSubmit("deploy", func(s *hanu.ViewSubmission) error {
+		var form deployForm
+		if err := s.Decode(&form); err != nil {
+			return err
+		}
+		if form.Service != "web" {
+			return hanu.ViewErrors{"service": "Only web can be deployed"}
+		}
+
+		return nil
+	})
+
+	resp := submitAck(t, h, submission("deploy", deployValues("three")))
+	if resp.ResponseAction != slack.RAErrors || resp.Errors["scale"] != "three is not a whole number" {
+		t.Errorf("values that cannot be decoded should be field errors, got %+v", resp)
+	}
+
+	resp = submitAck(t, h, submission("deploy", deployValues("3")))
+	if resp.ResponseAction != slack.RAErrors || !reflect.DeepEqual(resp.Errors, map[string]string{"service": "Only web can be deployed"}) {
+		t.Errorf("handler's errors should be sent, got %+v", resp)
+	}
+}
+
+func TestOnSubmitResponseActions(t *testing.T) {
+	h := hanutest.New(t)
+	confirm := slack.ModalViewRequest{Type: slack.VTModal, CallbackID: "confirm"}
+	h.Bot.OnSubmit("update", func(s *hanu.ViewSubmission) error {
+		s.Update(confirm)
+		return nil
+	})
+	h.Bot.OnSubmit("push", func(s *hanu.ViewSubmission) error {
+		s.Push(confirm)
+		return nil
+	})
+	h.Bot.OnSubmit("clear", func(s *hanu.ViewSubmission) error {
+		s.Clear()
+		return nil
+	})
+
+	for _, action := range []slack.ViewResponseAction{slack.RAUpdate, slack.RAPush, slack.RAClear} {
+		resp := submitAck(t, h, submission(string(action), nil))
+		if resp.ResponseAction != action {
+			t.Errorf("submission should be acknowledged with %s, got %+v", action, resp)
+		}
+		if action != slack.RAClear && (resp.View == nil || resp.View.CallbackID != "confirm") {
+			t.Errorf("%s should send the confirm view, got %+v", action, resp.View)
+		}
+	}
+}
+
+func TestDecodeUnsupportedField(t *testing.T) {
+	h := hanutest.New(t)
+	errs := make(chan error, 1)
+	h.Bot.OnSubmit("deploy", func(s *hanu.ViewSubmission) error {
+		var form struct {
+			Service map[string]int `hanu:"service"`
+		}
+		err := s.Decode(&form)
+		errs <- err
+		return err
+	})
+
+	h.Interaction(submission("deploy", deployValues("3"))).Ack()
+
+	err := <-errs
+	if _, ok := err.(hanu.ViewErrors); ok || err == nil {
+		t.Errorf("unsupported fields should fail decoding without field errors, got %v", err)
+	}
+}
@@ -0,0 +1,289 @@
+package hanu
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// ErrShutdown is the error of messages sent after the bot finished shutting
+// down
+var ErrShutdown = errors.New("bot is shut down")
+
+var (
+	// sendAttempts is how often a message is tried to be posted before it is
+	// reported as failed, rate limited attempts do not count
+	sendAttempts = 5
+	// sendBackoff is how long to wait before retrying a failed post, it
+	// doubles with every attempt
+	sendBackoff = 500 * time.Millisecond
+	// maxSendBackoff limits how long to wait between attempts
+	maxSendBackoff = 30 * time.Second
+)
+
+// SendFailureHandler is called with messages that could not be posted
+type SendFailureHandler func(msg MessageInterface, err error)
+
+// postFunc posts a message to a channel and returns the channel it was
+// posted to and its timestamp
+type postFunc func(ctx context.Context, channel string, options ...slack.MsgOption) (string, string, error)
+
+// outgoing is a message waiting to be posted
+type outgoing struct {
+	msg     MessageInterface
+	options []slack.MsgOption
+	done    chan struct{}
+	// channel is the channel the message was posted to, which differs from
+	// the message's channel for messages sent to a user ID
+	channel string
+	ts      string
+	err     error
+}
+
+// channelQueue holds the messages waiting to be posted to a channel and when
+// the channel's rate limit allows the next one
+type channelQueue struct {
+	messages []*outgoing
+	next     time.Time
+}
+
+// outbox posts messages in order per channel, spacing them out to stay
+// within Slack's rate limits and retrying failures
+type outbox struct {
+	mu       sync.Mutex
+	channels map[string]*channelQueue
+	interval time.Duration
+	burst    int
+	wg       sync.WaitGroup
+	closed   bool
+	ctx      context.Context
+	cancel   context.CancelFunc
+	post     postFunc
+	failed   func(msg MessageInterface, err error)
+}
+
+func newOutbox(post postFunc, failed func(msg MessageInterface, err error)) *outbox {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &outbox{
+		channels: make(map[string]*channelQueue),
+		interval: time.Second,
+		burst:    3,
+		ctx:      ctx,
+		cancel:   cancel,
+		post:     post,
+		failed:   failed,
+	}
+}
+
+// SetRateLimit will set how many messages can be posted to a channel in a
+// burst and how far apart messages are posted after that. By default bursts
+// of 3 messages are followed by one message per second, as Slack allows. An
+// interval of 0 turns rate limiting off.
+func (b *Bot) SetRateLimit(interval time.Duration, burst int) *Bot {
+	b.outbox.mu.Lock()
+	defer b.outbox.mu.Unlock()
+
+	b.outbox.interval = interval
+	b.outbox.burst = burst
+	return b
+}
+
+// SetSendFailureHandler will set the function messages that could not be
+// posted after retrying are reported to, they are logged in any case
+func (b *Bot) SetSendFailureHandler(h SendFailureHandler) *Bot {
+	b.sendFailureHandler = h
+	return b
+}
+
+// sendFailed reports a message that could not be posted
+func (b *Bot) sendFailed(msg MessageInterface, err error) {
+	b.metrics.sendFailed()
+	b.log().Error("failed posting message", "channel", msg.Channel(), "error", err)
+
+	if b.sendFailureHandler != nil {
+		b.sendFailureHandler(msg, err)
+	}
+}
+
+// enqueue adds a message to the queue of its channel, the returned message
+// is done once it was posted or failed. Messages enqueued once the outbox is
+// flushed fail right away.
+func (o *outbox) enqueue(msg MessageInterface, options []slack.MsgOption) *outgoing {
+	out := &outgoing{msg: msg, options: options, done: make(chan struct{})}
+
+	o.mu.Lock()
+	if o.closed {
+		o.mu.Unlock()
+
+		out.err = ErrShutdown
+		o.failed(out.msg, out.err)
+		close(out.done)
+		return out
+	}
+	defer o.mu.Unlock()
+
+	q, ok := o.channels[msg.Channel()]
+	if !ok {
+		q = &channelQueue{}
+		o.channels[msg.Channel()] = q
+	}
+
+	q.messages = append(q.messages, out)
+	if len(q.messages) == 1 {
+		o.wg.Add(1)
+		go o.run(msg.Channel(), q)
+	}
+
+	return out
+}
+
+// run posts the messages queued for a channel until the queue is empty
+func (o *outbox) run(channel string, q *channelQueue) {
+	defer o.wg.Done()
+
+	for {
+		o.mu.Lock()
+		out := q.messages[0]
+		o.mu.Unlock()
+
+		out.channel, out.ts, out.err = o.send(channel, q, out)
+		if out.err != nil {
+			o.failed(out.msg, out.err)
+		}
+		close(out.done)
+
+		o.mu.Lock()
+		q.messages = q.messages[1:]
+		if len(q.messages) == 0 {
+			o.mu.Unlock()
+			return
+		}
+		o.mu.Unlock()
+	}
+}
+
+// send posts a message, retrying rate limited posts and transient failures
+func (o *outbox) send(channel string, q *channelQueue, out *outgoing) (string, string, error) {
+	for attempt := 1; ; {
+		if err := o.wait(q); err != nil {
+			return "", "", err
+		}
+
+		posted, ts, err := o.post(o.ctx, channel, out.options...)
+		if err == nil {
+			return posted, ts, nil
+		}
+
+		delay := backoff(attempt)
+
+		var limited *slack.RateLimitedError
+		if errors.As(err, &limited) {
+			delay = limited.RetryAfter
+		} else if !isTransient(err) || attempt >= sendAttempts {
+			return "", "", err
+		} else {
+			attempt++
+		}
+
+		if err := o.sleep(delay); err != nil {
+			return "", "", err
+		}
+	}
+}
+
+// wait blocks until the channel's rate limit allows the next message
+func (o *outbox) wait(q *channelQueue) error {
+	o.mu.Lock()
+	now := time.Now()
+	if o.interval <= 0 {
+		o.mu.Unlock()
+		return nil
+	}
+
+	// the channel may post burst messages ahead of schedule
+	if q.next.Before(now) {
+		q.next = now
+	}
+	delay := q.next.Sub(now) - time.Duration(o.burst-1)*o.interval
+	q.next = q.next.Add(o.interval)
+	o.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	return o.sleep(delay)
+}
+
+// sleep waits for d or until the outbox is aborted
+func (o *outbox) sleep(d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-o.ctx.Done():
+		return o.ctx.Err()
+	}
+}
+
+// close stops accepting messages
+func (o *outbox) close() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.closed = true
+}
+
+// abort stops accepting messages and fails the queued ones
+func (o *outbox) abort() {
+	o.close()
+	o.cancel()
+}
+
+// flush stops accepting messages and waits until all queued messages were
+// posted or failed, or until ctx is done, then the remaining messages fail
+func (o *outbox) flush(ctx context.Context) error {
+	o.close()
+
+	done := make(chan struct{})
+	go func() {
+		o.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		o.cancel()
+		return ctx.Err()
+	}
+}
+
+// backoff returns how long to wait after a failed attempt
+func backoff(attempt int) time.Duration {
+	d := sendBackoff << (attempt - 1)
+	if d <= 0 || d > maxSendBackoff {
+		return maxSendBackoff
+	}
+
+	return d
+}
+
+// isTransient checks if posting a message may succeed when retried
+func isTransient(err error) bool {
+	var retryable interface{ Retryable() bool }
+	if errors.As(err, &retryable) {
+		return retryable.Retryable()
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
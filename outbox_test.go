@@ -0,0 +1,171 @@
+package hanu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// fakePoster answers posts with the given errors in turn, then succeeds
+type fakePoster struct {
+	mu     sync.Mutex
+	errs   []error
+	posts  []time.Time
+	failed chan error
+}
+
+func (p *fakePoster) post(ctx context.Context, channel string, options ...slack.MsgOption) (string, string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.posts = append(p.posts, time.Now())
+	if len(p.errs) > 0 {
+		err := p.errs[0]
+		p.errs = p.errs[1:]
+		return "", "", err
+	}
+
+	return channel, fmt.Sprintf("1700000000.%06d", len(p.posts)), nil
+}
+
+func (p *fakePoster) outbox() *outbox {
+	p.failed = make(chan error, 10)
+	o := newOutbox(p.post, func(msg MessageInterface, err error) {
+		p.failed <- err
+	})
+	o.interval = 0
+
+	return o
+}
+
+func (p *fakePoster) count() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.posts)
+}
+
+func fastBackoff(t *testing.T) {
+	backoff, attempts := sendBackoff, sendAttempts
+	sendBackoff, sendAttempts = time.Millisecond, 3
+	t.Cleanup(func() {
+		sendBackoff, sendAttempts = backoff, attempts
+	})
+}
+
+func TestOutboxRateLimit(t *testing.T) {
+	p := &fakePoster{}
+	o := p.outbox()
+	o.interval = 50 * time.Millisecond
+	o.burst = 2
+
+	start := time.Now()
+	var outs []*outgoing
+	for i := 0; i < 4; i++ {
+		outs = append(outs, o.enqueue(Message{ChannelID: "C1"}, nil))
+	}
+	<-outs[3].done
+
+	for i, min := range []time.Duration{0, 0, 50 * time.Millisecond, 100 * time.Millisecond} {
+		if d := p.posts[i].Sub(start); d < min {
+			t.Errorf("message %d should be posted after %s, was posted after %s", i, min, d)
+		}
+	}
+
+	if outs[3].ts != "1700000000.000004" {
+		t.Errorf("messages should be posted in order, the last one is %s", outs[3].ts)
+	}
+}
+
+func TestOutboxRetriesRateLimited(t *testing.T) {
+	fastBackoff(t)
+	p := &fakePoster{errs: []error{
+		&slack.RateLimitedError{RetryAfter: 20 * time.Millisecond},
+		&slack.RateLimitedError{RetryAfter: 20 * time.Millisecond},
+		&slack.RateLimitedError{RetryAfter: 20 * time.Millisecond},
+	}}
+	o := p.outbox()
+
+	start := time.Now()
+	out := o.enqueue(Message{ChannelID: "C1"}, nil)
+	<-out.done
+
+	if out.err != nil || p.count() != 4 {
+		t.Errorf("rate limited posts should be retried regardless of attempts, got %v after %d posts", out.err, p.count())
+	}
+
+	if d := time.Since(start); d < 60*time.Millisecond {
+		t.Errorf("retries should wait as long as Slack asks, waited %s", d)
+	}
+}
+
+func TestOutboxRetriesTransientFailures(t *testing.T) {
+	fastBackoff(t)
+	p := &fakePoster{errs: []error{
+		slack.StatusCodeError{Code: 500, Status: "500 Internal Server Error"},
+		slack.StatusCodeError{Code: 503, Status: "503 Service Unavailable"},
+	}}
+	o := p.outbox()
+
+	out := o.enqueue(Message{ChannelID: "C1"}, nil)
+	<-out.done
+
+	if out.err != nil || p.count() != 3 {
+		t.Errorf("transient failures should be retried, got %v after %d posts", out.err, p.count())
+	}
+}
+
+func TestOutboxReportsFailures(t *testing.T) {
+	fastBackoff(t)
+
+	data := []struct {
+		errs  []error
+		posts int
+	}{
+		{[]error{slack.SlackErrorResponse{Err: "channel_not_found"}}, 1},
+		{[]error{
+			slack.StatusCodeError{Code: 500},
+			slack.StatusCodeError{Code: 500},
+			slack.StatusCodeError{Code: 500},
+		}, 3},
+	}
+
+	for _, d := range data {
+		p := &fakePoster{errs: d.errs}
+		o := p.outbox()
+
+		out := o.enqueue(Message{ChannelID: "C1"}, nil)
+		<-out.done
+
+		if err := <-p.failed; err.Error() != d.errs[len(d.errs)-1].Error() {
+			t.Errorf("the last error should be reported, got %v", err)
+		}
+
+		if p.count() != d.posts {
+			t.Errorf("%v should be tried %d times, was tried %d times", d.errs[0], d.posts, p.count())
+		}
+	}
+}
+
+func TestOutboxFlush(t *testing.T) {
+	p := &fakePoster{errs: []error{&slack.RateLimitedError{RetryAfter: time.Minute}}}
+	o := p.outbox()
+
+	o.enqueue(Message{ChannelID: "C1"}, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := o.flush(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("flush should give up at the deadline, got %v", err)
+	}
+
+	if err := <-p.failed; !errors.Is(err, context.Canceled) {
+		t.Errorf("messages still queued should fail, got %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+package hanu
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// groupMembersTTL is how long the members of a user group are remembered,
+// Slack limits how often they can be listed
+var groupMembersTTL = time.Minute
+
+// Permissions restrict who can run a command and where, empty lists do not
+// restrict anything
+type Permissions struct {
+	// Users lists the IDs of users allowed to run the command
+	Users []string
+	// UserGroups lists the IDs of user groups whose members are allowed to
+	// run the command
+	UserGroups []string
+	// Channels lists the IDs of channels the command can be run in
+	Channels []string
+}
+
+// IsRestricted checks if the permissions restrict anything
+func (p Permissions) IsRestricted() bool {
+	return len(p.Users) > 0 || len(p.UserGroups) > 0 || len(p.Channels) > 0
+}
+
+// allows checks if the sender of the message may run a command in the
+// message's channel, members looks up the user IDs in a user group
+func (p Permissions) allows(msg MessageInterface, members func(group string) ([]string, error)) (bool, error) {
+	if len(p.Channels) > 0 && !contains(p.Channels, msg.Channel()) {
+		return false, nil
+	}
+
+	if len(p.Users) == 0 && len(p.UserGroups) == 0 {
+		return true, nil
+	}
+
+	if contains(p.Users, msg.User()) {
+		return true, nil
+	}
+
+	for _, group := range p.UserGroups {
+		users, err := members(group)
+		if err != nil {
+			return false, err
+		}
+
+		if contains(users, msg.User()) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// SetDenyReply will set the reply sent when a user is not allowed to run a
+// command, an empty text denies silently
+func (b *Bot) SetDenyReply(text string) *Bot {
+	b.denyReply = text
+	return b
+}
+
+// IsAllowed checks if the sender of the message may run the command,
+// commands without permissions can be run by everybody. An error is returned
+// if the members of a user group could not be looked up.
+func (b *Bot) IsAllowed(ctx context.Context, cmd CommandInterface, msg MessageInterface) (bool, error) {
+	return b.isAllowed(cmd, msg, b.groupMembers(ctx))
+}
+
+func (b *Bot) isAllowed(cmd CommandInterface, msg MessageInterface, members func(group string) ([]string, error)) (bool, error) {
+	var sets []Permissions
+	switch c := cmd.(type) {
+	case interface{ permissionSets() []Permissions }:
+		sets = c.permissionSets()
+	case interface{ Permissions() Permissions }:
+		sets = []Permissions{c.Permissions()}
+	}
+
+	for _, p := range sets {
+		if !p.IsRestricted() {
+			continue
+		}
+
+		allowed, err := p.allows(msg, members)
+		if err != nil {
+			return false, fmt.Errorf("checking permissions of %s: %w", cmd.Get().Text(), err)
+		}
+
+		if !allowed {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// isListed checks if the command is listed in help and usage replies to the
+// message, commands whose permissions cannot be checked are not
+func (b *Bot) isListed(cmd CommandInterface, msg MessageInterface, members func(group string) ([]string, error)) bool {
+	allowed, err := b.isAllowed(cmd, msg, members)
+	if err != nil {
+		b.log().Error("checking permissions failed", append(messageAttrs(msg), "command", cmd.Get().Text(), "error", err)...)
+	}
+
+	return allowed
+}
+
+// groupMembers returns a lookup of user group members within ctx
+func (b *Bot) groupMembers(ctx context.Context) func(group string) ([]string, error) {
+	return func(group string) ([]string, error) {
+		return b.groups.members(group, func() ([]string, error) {
+			return b.SocketClient.GetUserGroupMembersContext(ctx, group)
+		})
+	}
+}
+
+// groupCache remembers the members of user groups for groupMembersTTL
+type groupCache struct {
+	mu     sync.Mutex
+	groups map[string]cachedGroup
+}
+
+// cachedGroup is the members of a user group and when they were listed
+type cachedGroup struct {
+	users  []string
+	listed time.Time
+}
+
+// members returns the members of the group, listing them with list unless
+// they were listed recently
+func (c *groupCache) members(group string, list func() ([]string, error)) ([]string, error) {
+	c.mu.Lock()
+	cached, ok := c.groups[group]
+	c.mu.Unlock()
+
+	if ok && time.Since(cached.listed) < groupMembersTTL {
+		return cached.users, nil
+	}
+
+	users, err := list()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.groups == nil {
+		c.groups = make(map[string]cachedGroup)
+	}
+	c.groups[group] = cachedGroup{users: users, listed: time.Now()}
+
+	return users, nil
+}
@@ -0,0 +1,57 @@
+package hanu
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPermissions(t *testing.T) {
+	members := func(group string) ([]string, error) {
+		if group == "SADMINS" {
+			return []string{"UADMIN"}, nil
+		}
+		return nil, errors.New("no_such_subteam")
+	}
+
+	var data = []struct {
+		perm    Permissions
+		user    string
+		channel string
+		allowed bool
+		err     bool
+	}{
+		{Permissions{}, "U1", "C1", true, false},
+		{Permissions{Users: []string{"U1"}}, "U1", "C1", true, false},
+		{Permissions{Users: []string{"U1"}}, "U2", "C1", false, false},
+		{Permissions{Channels: []string{"C1"}}, "U2", "C1", true, false},
+		{Permissions{Channels: []string{"C1"}}, "U2", "C2", false, false},
+		{Permissions{Users: []string{"U1"}, Channels: []string{"C1"}}, "U1", "C2", false, false},
+		{Permissions{UserGroups: []string{"SADMINS"}}, "UADMIN", "C1", true, false},
+		{Permissions{UserGroups: []string{"SADMINS"}}, "U1", "C1", false, false},
+		{Permissions{Users: []string{"U1"}, UserGroups: []string{"SADMINS"}}, "U1", "C1", true, false},
+		{Permissions{UserGroups: []string{"SNOPE"}}, "U1", "C1", false, true},
+	}
+
+	for _, set := range data {
+		msg := Message{UserID: set.user, ChannelID: set.channel}
+
+		allowed, err := set.perm.allows(msg, members)
+		if allowed != set.allowed || (err != nil) != set.err {
+			t.Errorf("%+v should allow %s in %s: %v (error %v), got %v (%v)", set.perm, set.user, set.channel, set.allowed, set.err, allowed, err)
+		}
+	}
+}
+
+func TestCommandPermissions(t *testing.T) {
+	cmd := NewCommand("cmd", "Description", func(conv Convo) {})
+
+	if cmd.Permissions().IsRestricted() {
+		t.Errorf("new command should not be restricted")
+	}
+
+	cmd.SetPermissions(Permissions{Users: []string{"U1"}})
+
+	if !cmd.Permissions().IsRestricted() {
+		t.Errorf("command should be restricted")
+	}
+}
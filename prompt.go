@@ -0,0 +1,72 @@
+package hanu
+
+import (
+	"sync"
+)
+
+// prompts keeps track of handlers waiting for an answer from a user
+type prompts struct {
+	mu      sync.Mutex
+	waiting map[string][]chan Message
+}
+
+// promptKey identifies the user, channel and thread an answer must come from
+func promptKey(msg MessageInterface) string {
+	return msg.Channel() + "/" + msg.ThreadTimestamp() + "/" + msg.User()
+}
+
+// wait registers a handler waiting for the next message matching key, the
+// returned function must be called once the handler stops waiting
+func (p *prompts) wait(key string) (<-chan Message, func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.waiting == nil {
+		p.waiting = make(map[string][]chan Message)
+	}
+
+	ch := make(chan Message, 1)
+	p.waiting[key] = append(p.waiting[key], ch)
+
+	return ch, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		p.remove(key, ch)
+	}
+}
+
+// answer hands the message to the handler that has been waiting the longest
+// for it and reports whether there was one
+func (p *prompts) answer(msg Message) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := promptKey(msg)
+	if len(p.waiting[key]) == 0 {
+		return false
+	}
+
+	ch := p.waiting[key][0]
+	p.remove(key, ch)
+	ch <- msg
+
+	return true
+}
+
+func (p *prompts) remove(key string, ch chan Message) {
+	waiting := p.waiting[key]
+	for i := 0; i < len(waiting); i++ {
+		if waiting[i] == ch {
+			waiting = append(waiting[:i], waiting[i+1:]...)
+			break
+		}
+	}
+
+	if len(waiting) == 0 {
+		delete(p.waiting, key)
+		return
+	}
+
+	p.waiting[key] = waiting
+}
@@ -0,0 +1,70 @@
+package hanu
+
+import "testing"
+
+func TestPromptAnswer(t *testing.T) {
+	p := prompts{}
+	question := Message{ChannelID: "C1", UserID: "U1", ThreadTimeStamp: "1.1"}
+
+	answers, cancel := p.wait(promptKey(question))
+	defer cancel()
+
+	var data = []struct {
+		msg    Message
+		answer bool
+	}{
+		{Message{ChannelID: "C1", UserID: "U2", ThreadTimeStamp: "1.1"}, false},
+		{Message{ChannelID: "C2", UserID: "U1", ThreadTimeStamp: "1.1"}, false},
+		{Message{ChannelID: "C1", UserID: "U1"}, false},
+		{Message{ChannelID: "C1", UserID: "U1", ThreadTimeStamp: "1.1", Message: "prod"}, true},
+		{Message{ChannelID: "C1", UserID: "U1", ThreadTimeStamp: "1.1"}, false},
+	}
+
+	for _, set := range data {
+		if p.answer(set.msg) != set.answer {
+			t.Errorf("answer(%+v) should be %v", set.msg, set.answer)
+		}
+	}
+
+	if msg := <-answers; msg.Text() != "prod" {
+		t.Errorf("answer should be \"prod\", is \"%s\"", msg.Text())
+	}
+}
+
+func TestPromptOrder(t *testing.T) {
+	p := prompts{}
+	msg := Message{ChannelID: "C1", UserID: "U1"}
+
+	first, cancelFirst := p.wait(promptKey(msg))
+	second, cancelSecond := p.wait(promptKey(msg))
+	defer cancelSecond()
+
+	msg.SetText("one")
+	p.answer(msg)
+	if answer := <-first; answer.Text() != "one" {
+		t.Errorf("first question should be answered first, got \"%s\"", answer.Text())
+	}
+	cancelFirst()
+
+	msg.SetText("two")
+	p.answer(msg)
+	if answer := <-second; answer.Text() != "two" {
+		t.Errorf("second question should get the second answer, got \"%s\"", answer.Text())
+	}
+
+	if len(p.waiting) != 0 {
+		t.Errorf("no question should be pending, %d are", len(p.waiting))
+	}
+}
+
+func TestPromptCancel(t *testing.T) {
+	p := prompts{}
+	msg := Message{ChannelID: "C1", UserID: "U1"}
+
+	_, cancel := p.wait(promptKey(msg))
+	cancel()
+
+	if p.answer(msg) {
+		t.Errorf("cancelled question should not be answered")
+	}
+}
@@ -0,0 +1,218 @@
+package hanu
+
+import (
+	"context"
+	"runtime/debug"
+	"strings"
+	"sync/atomic"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/slack-go/slack/socketmode"
+)
+
+// Reactions the bot adds to commands when acknowledgement reactions are on
+const (
+	ReactionStarted   = "eyes"
+	ReactionSucceeded = "white_check_mark"
+	ReactionFailed    = "x"
+)
+
+// Reaction is an emoji reaction added to or removed from a message
+type Reaction struct {
+	// Name is the emoji's name without colons, e.g. "white_check_mark"
+	Name string
+	// User is the user who reacted
+	User string
+	// ItemUser is the author of the message reacted to
+	ItemUser string
+	// Channel and Timestamp identify the message reacted to
+	Channel   string
+	Timestamp string
+	// Removed is set if the reaction was removed
+	Removed bool
+
+	bot *Bot
+	ctx context.Context
+}
+
+// Context returns the context of the event, it is cancelled when the bot
+// stops listening or shuts down
+func (r Reaction) Context() context.Context {
+	return r.ctx
+}
+
+// ReplyInThread answers in the thread of the message reacted to
+func (r Reaction) ReplyInThread(text string, a ...interface{}) *SentMessage {
+	return r.bot.SayInThread(r.Channel, r.Timestamp, text, a...).WithContext(r.ctx)
+}
+
+// ReactionHandler handles a reaction
+type ReactionHandler func(r Reaction)
+
+// reactionHandler is a handler registered for reactions of a name
+type reactionHandler struct {
+	name     string
+	removed  bool
+	channels []string
+	handler  ReactionHandler
+}
+
+// matches checks if the handler was registered for the reaction
+func (h reactionHandler) matches(r Reaction) bool {
+	if h.removed != r.Removed || (h.name != "" && h.name != r.Name) {
+		return false
+	}
+
+	if len(h.channels) == 0 {
+		return true
+	}
+
+	for _, ch := range h.channels {
+		if ch == r.Channel {
+			return true
+		}
+	}
+
+	return false
+}
+
+// OnReactionAdded adds a handler called when a reaction of the given name,
+// e.g. ":white_check_mark:", is added to a message in one of the channels.
+// An empty name matches every reaction, no channels match every channel.
+func (b *Bot) OnReactionAdded(name string, handler ReactionHandler, channels ...string) {
+	b.reactionHandlers = append(b.reactionHandlers, reactionHandler{reactionName(name), false, channels, handler})
+}
+
+// OnReactionRemoved adds a handler called when a reaction of the given name
+// is removed from a message in one of the channels
+func (b *Bot) OnReactionRemoved(name string, handler ReactionHandler, channels ...string) {
+	b.reactionHandlers = append(b.reactionHandlers, reactionHandler{reactionName(name), true, channels, handler})
+}
+
+// reactionName strips the colons and skin tone from an emoji name
+func reactionName(name string) string {
+	name = strings.Trim(name, ":")
+	if i := strings.Index(name, "::"); i >= 0 {
+		name = name[:i]
+	}
+
+	return name
+}
+
+func middlewareReactionEventWithBot(b *Bot) eventHandler {
+	return func(ctx context.Context, evt *socketmode.Event, client *socketmode.Client) {
+		eventsAPIEvent, ok := evt.Data.(slackevents.EventsAPIEvent)
+		if !ok {
+			b.log().Debug("ignored event", "event_type", evt.Type)
+			return
+		}
+
+		b.Ack(*evt.Request)
+
+		var r Reaction
+		switch ev := eventsAPIEvent.InnerEvent.Data.(type) {
+		case *slackevents.ReactionAddedEvent:
+			r = Reaction{Name: ev.Reaction, User: ev.User, ItemUser: ev.ItemUser, Channel: ev.Item.Channel, Timestamp: ev.Item.Timestamp}
+		case *slackevents.ReactionRemovedEvent:
+			r = Reaction{Name: ev.Reaction, User: ev.User, ItemUser: ev.ItemUser, Channel: ev.Item.Channel, Timestamp: ev.Item.Timestamp, Removed: true}
+		default:
+			b.log().Debug("ignored event", "event_type", evt.Type, "inner_event_type", eventsAPIEvent.InnerEvent.Type)
+			return
+		}
+
+		// The bot's own acknowledgements are not handled
+		if r.User == b.ID {
+			return
+		}
+
+		r.Name = reactionName(r.Name)
+		r.bot = b
+		r.ctx = ctx
+		b.handleReaction(r)
+	}
+}
+
+// handleReaction calls the handlers registered for the reaction
+func (b *Bot) handleReaction(r Reaction) {
+	for _, h := range b.reactionHandlers {
+		if h.matches(r) {
+			b.log().Debug("dispatching reaction", "channel", r.Channel, "user", r.User, "reaction", r.Name)
+			b.runReactionHandler(h.handler, r)
+		}
+	}
+}
+
+func (b *Bot) runReactionHandler(h ReactionHandler, r Reaction) {
+	defer func() {
+		if v := recover(); v != nil {
+			b.log().Error("reaction handler failed", "channel", r.Channel, "user", r.User, "reaction", r.Name, "error", &PanicError{Value: v, Stack: debug.Stack()})
+		}
+	}()
+
+	h(r)
+}
+
+// SetAckReactions will make the bot react to commands with eyes while
+// handling them, then with a check mark or a cross once they succeeded or
+// failed
+func (b *Bot) SetAckReactions(ar bool) *Bot {
+	b.ackReactions = ar
+	return b
+}
+
+// outcomeKey is the context key of the outcome of a command
+type outcomeKey struct{}
+
+// withOutcome returns a context recording whether the command failed
+func withOutcome(ctx context.Context) (context.Context, *atomic.Bool) {
+	failed := &atomic.Bool{}
+	return context.WithValue(ctx, outcomeKey{}, failed), failed
+}
+
+// markFailed records that the command of the conversation failed
+func markFailed(conv Convo) {
+	ctx := conv.Context()
+	if ctx == nil {
+		return
+	}
+
+	if failed, ok := ctx.Value(outcomeKey{}).(*atomic.Bool); ok {
+		failed.Store(true)
+	}
+}
+
+// react adds or removes a reaction to a message, failures are only logged
+func (b *Bot) react(msg MessageInterface, name string, remove bool) {
+	ref := slack.NewRefToMessage(msg.Channel(), msg.Timestamp())
+
+	var err error
+	if remove {
+		err = b.SocketClient.RemoveReactionContext(context.Background(), name, ref)
+	} else {
+		err = b.SocketClient.AddReactionContext(context.Background(), name, ref)
+	}
+
+	if err != nil {
+		b.log().Warn("failed reacting to message", append(messageAttrs(msg), "reaction", name, "error", err)...)
+	}
+}
+
+// acknowledge runs a command between reactions showing its progress if the
+// bot is set to
+func (b *Bot) acknowledge(msg MessageInterface, failed *atomic.Bool, run func()) {
+	if !b.ackReactions {
+		run()
+		return
+	}
+
+	b.react(msg, ReactionStarted, false)
+	run()
+	b.react(msg, ReactionStarted, true)
+
+	if failed.Load() {
+		b.react(msg, ReactionFailed, false)
+		return
+	}
+	b.react(msg, ReactionSucceeded, false)
+}
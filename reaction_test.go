@@ -0,0 +1,106 @@
+package hanu_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ChrisMcKee/hanu"
+	"github.com/ChrisMcKee/hanu/hanutest"
+)
+
+func TestBotReactionHandlers(t *testing.T) {
+	h := hanutest.New(t)
+	reactions := make(chan hanu.Reaction, 4)
+	h.Bot.OnReactionAdded(":white_check_mark:", func(r hanu.Reaction) {
+		reactions <- r
+		r.ReplyInThread("approved by <@%s>", r.User)
+	}, "C1")
+	h.Bot.OnReactionRemoved("thumbsup", func(r hanu.Reaction) {
+		reactions <- r
+	})
+
+	h.Reaction("U1", "C2", "1600000000.000001", "white_check_mark")
+	h.Reaction("U1", "C1", "1600000000.000001", "eyes")
+	h.Reaction("U1", "C1", "1600000000.000001", "white_check_mark")
+
+	r := <-reactions
+	if r.Name != "white_check_mark" || r.Channel != "C1" || r.User != "U1" || r.Removed {
+		t.Errorf("only the check mark added in C1 should be handled, got %+v", r)
+	}
+
+	if post := h.NextPost(); post.ThreadTimestamp != "1600000000.000001" || post.Text != "approved by <@U1>" {
+		t.Errorf("reply should be posted in the thread of the message, got \"%s\" in thread \"%s\"", post.Text, post.ThreadTimestamp)
+	}
+
+	h.ReactionRemoved("U2", "C2", "1600000000.000001", "thumbsup::skin-tone-2")
+
+	if r := <-reactions; r.Name != "thumbsup" || !r.Removed {
+		t.Errorf("removed thumbs up should be handled regardless of skin tone, got %+v", r)
+	}
+
+	select {
+	case r := <-reactions:
+		t.Errorf("no other reaction should be handled, got %+v", r)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestBotIgnoresOwnReactions(t *testing.T) {
+	h := hanutest.New(t)
+	reactions := make(chan hanu.Reaction, 1)
+	h.Bot.OnReactionAdded("", func(r hanu.Reaction) {
+		reactions <- r
+	})
+
+	h.Reaction(h.Bot.ID, "C1", "1600000000.000001", "eyes")
+
+	select {
+	case r := <-reactions:
+		t.Errorf("reactions of the bot should not be handled, got %+v", r)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestBotAckReactions(t *testing.T) {
+	data := []struct {
+		handler hanu.HandlerE
+		result  string
+	}{
+		{func(conv hanu.Convo) error { return nil }, hanu.ReactionSucceeded},
+		{func(conv hanu.Convo) error { return errors.New("failed") }, hanu.ReactionFailed},
+		{func(conv hanu.Convo) error { panic("failed") }, hanu.ReactionFailed},
+	}
+
+	for _, d := range data {
+		h := hanutest.New(t)
+		h.Bot.SetAckReactions(true)
+		h.Bot.SetErrorHandler(func(conv hanu.Convo, err error) {})
+		done := make(chan struct{})
+		h.Bot.CommandE("deploy", func(conv hanu.Convo) error {
+			defer close(done)
+			return d.handler(conv)
+		})
+
+		ts := h.Message("U1", "C1", "deploy")
+		<-done
+
+		deadline := time.Now().Add(hanutest.Timeout)
+		for len(h.Server.Calls("reactions.add")) < 2 && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+
+		added := h.Server.Calls("reactions.add")
+		if len(added) != 2 || added[0].Values.Get("name") != hanu.ReactionStarted || added[1].Values.Get("name") != d.result {
+			t.Errorf("command should be acknowledged with %s then %s, got %v", hanu.ReactionStarted, d.result, added)
+			continue
+		}
+		if added[1].Values.Get("channel") != "C1" || added[1].Values.Get("timestamp") != ts {
+			t.Errorf("reaction should be added to message %s in C1, got %v", ts, added[1].Values)
+		}
+
+		if removed := h.Server.Calls("reactions.remove"); len(removed) != 1 || removed[0].Values.Get("name") != hanu.ReactionStarted {
+			t.Errorf("%s should be removed once the command is done, got %v", hanu.ReactionStarted, removed)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package hanu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/slack-go/slack"
+)
+
+// ErrNotPosted is returned when changing a message that was not posted
+var ErrNotPosted = errors.New("message was not posted")
+
+// SentMessage is a message the bot sent, it can be changed once posted.
+// Messages are posted asynchronously, the methods wait until the message was
+// posted.
+type SentMessage struct {
+	bot *Bot
+	out *outgoing
+	ctx context.Context
+}
+
+// Wait blocks until the message was posted and returns the error if it
+// could not be
+func (m *SentMessage) Wait() error {
+	if m == nil {
+		return ErrNotPosted
+	}
+
+	<-m.out.done
+	return m.out.err
+}
+
+// WithContext returns a copy of the handle whose changes to the message use
+// ctx, replies of conversations use the conversation's context
+func (m *SentMessage) WithContext(ctx context.Context) *SentMessage {
+	if m == nil {
+		return nil
+	}
+
+	c := *m
+	c.ctx = ctx
+	return &c
+}
+
+// context returns the context changes to the message use
+func (m *SentMessage) context() context.Context {
+	if m.ctx == nil {
+		return context.Background()
+	}
+
+	return m.ctx
+}
+
+// Channel returns the ID of the channel the message was posted to, e.g. the
+// direct message channel of messages sent to a user ID. It waits until the
+// message was posted and is the channel it was sent to if it could not be.
+func (m *SentMessage) Channel() string {
+	if m == nil {
+		return ""
+	}
+
+	if m.Wait() != nil || m.out.channel == "" {
+		return m.out.msg.Channel()
+	}
+
+	return m.out.channel
+}
+
+// Timestamp returns the timestamp identifying the message, it is empty if
+// the message could not be posted
+func (m *SentMessage) Timestamp() string {
+	if m.Wait() != nil {
+		return ""
+	}
+
+	return m.out.ts
+}
+
+// Update replaces the text of the message
+func (m *SentMessage) Update(text string, a ...interface{}) error {
+	return m.update(slack.MsgOptionText(fmt.Sprintf(text, a...), false))
+}
+
+// UpdateBlocks replaces the message with a Block Kit message
+func (m *SentMessage) UpdateBlocks(blocks *Blocks) error {
+	return m.update(slack.MsgOptionText(blocks.FallbackText(), false), slack.MsgOptionBlocks(blocks.Build()...))
+}
+
+func (m *SentMessage) update(options ...slack.MsgOption) error {
+	if err := m.Wait(); err != nil {
+		return err
+	}
+
+	_, _, _, err := m.bot.SocketClient.UpdateMessageContext(m.context(), m.Channel(), m.out.ts, options...)
+	return err
+}
+
+// Delete removes the message
+func (m *SentMessage) Delete() error {
+	if err := m.Wait(); err != nil {
+		return err
+	}
+
+	_, _, err := m.bot.SocketClient.DeleteMessageContext(m.context(), m.Channel(), m.out.ts)
+	return err
+}
+
+// AddReaction reacts to the message with the emoji of the given name, e.g.
+// "white_check_mark"
+func (m *SentMessage) AddReaction(name string) error {
+	if err := m.Wait(); err != nil {
+		return err
+	}
+
+	return m.bot.SocketClient.AddReactionContext(m.context(), name, slack.NewRefToMessage(m.Channel(), m.out.ts))
+}
+
+// ReplyInThread answers in the thread of the message, starting a new thread
+// if the message was not posted in one
+func (m *SentMessage) ReplyInThread(text string, a ...interface{}) *SentMessage {
+	if m.Wait() != nil {
+		return nil
+	}
+
+	ts := m.out.msg.ThreadTimestamp()
+	if ts == "" {
+		ts = m.out.ts
+	}
+
+	return m.bot.SayInThread(m.Channel(), ts, text, a...).WithContext(m.ctx)
+}
+
+// postedMessage returns the handle of a message that was already posted, e.g.
+// the message an action was taken in
+func (b *Bot) postedMessage(channel, ts, threadTS string) *SentMessage {
+	out := &outgoing{msg: Message{ChannelID: channel, ThreadTimeStamp: threadTS}, channel: channel, ts: ts, done: make(chan struct{})}
+	close(out.done)
+
+	return b.sent(out)
+}
@@ -0,0 +1,104 @@
+package hanu_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ChrisMcKee/hanu"
+	"github.com/ChrisMcKee/hanu/hanutest"
+)
+
+func TestSentMessage(t *testing.T) {
+	h := hanutest.New(t)
+	errs := make(chan error, 4)
+	h.Bot.Command("migrate", func(conv hanu.Convo) {
+		progress := conv.Reply("migrating")
+		errs <- progress.Update("migrated %d tables", 3)
+		errs <- progress.AddReaction("white_check_mark")
+		errs <- progress.ReplyInThread("details").Wait()
+		errs <- progress.Delete()
+	})
+
+	h.Message("U1", "C1", "migrate")
+
+	post := h.NextPost()
+	for i := 0; i < 4; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("changing the message should succeed, got %v", err)
+		}
+	}
+
+	if reply := h.NextPost(); reply.ThreadTimestamp != post.Timestamp || reply.Text != "details" {
+		t.Errorf("reply should be posted in thread %s, got \"%s\" in thread \"%s\"", post.Timestamp, reply.Text, reply.ThreadTimestamp)
+	}
+
+	data := []struct {
+		method string
+		text   string
+	}{
+		{"chat.update", "migrated 3 tables"},
+		{"reactions.add", ""},
+		{"chat.delete", ""},
+	}
+
+	for _, d := range data {
+		calls := h.Server.Calls(d.method)
+		if len(calls) != 1 {
+			t.Errorf("%s should be called once, was called %d times", d.method, len(calls))
+			continue
+		}
+
+		v := calls[0].Values
+		if v.Get("channel") != "C1" || v.Get("timestamp")+v.Get("ts") != post.Timestamp {
+			t.Errorf("%s should refer to message %s in C1, got %v", d.method, post.Timestamp, v)
+		}
+		if v.Get("text") != d.text {
+			t.Errorf("%s should send \"%s\", sent \"%s\"", d.method, d.text, v.Get("text"))
+		}
+	}
+
+	if name := h.Server.Calls("reactions.add")[0].Values.Get("name"); name != "white_check_mark" {
+		t.Errorf("reaction should be white_check_mark, is %s", name)
+	}
+}
+
+func TestSentMessageNotPosted(t *testing.T) {
+	var m *hanu.SentMessage
+	if err := m.Update("text"); err != hanu.ErrNotPosted {
+		t.Errorf("updating a message that was not sent should fail, got %v", err)
+	}
+	if m.Timestamp() != "" || m.ReplyInThread("text") != nil {
+		t.Error("a message that was not sent should have no timestamp or replies")
+	}
+}
+
+func TestSentMessageToUser(t *testing.T) {
+	h := hanutest.New(t)
+
+	msg := h.Bot.Say("U1", "hello")
+	if err := msg.Update("hello again"); err != nil {
+		t.Fatalf("message should be updated, got %v", err)
+	}
+
+	if msg.Channel() != "D1" {
+		t.Errorf("message sent to U1 should be in its direct message channel D1, is in %s", msg.Channel())
+	}
+
+	calls := h.Server.Calls("chat.update")
+	if len(calls) != 1 || calls[0].Values.Get("channel") != "D1" {
+		t.Errorf("message should be updated in the channel it was posted to, got %v", calls)
+	}
+}
+
+func TestSentMessageWithContext(t *testing.T) {
+	h := hanutest.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	msg := h.Bot.Say("C1", "hello").WithContext(ctx)
+	if err := msg.Update("hello again"); !errors.Is(err, context.Canceled) {
+		t.Errorf("update should use the handle's context, got %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+package hanu
+
+import (
+	"context"
+	"sync"
+)
+
+// inflight keeps track of running event handlers, so shutting down can wait
+// for them to finish
+type inflight struct {
+	mu       sync.Mutex
+	stopped  bool
+	stopping chan struct{}
+	wg       sync.WaitGroup
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+// start registers a handler about to run and returns its context, which is
+// derived from parent and cancelled when the bot is done shutting down. The
+// returned function must be called once the handler finished. It reports
+// false once the bot is shutting down.
+func (f *inflight) start(parent context.Context) (context.Context, func(), bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.stopped {
+		return nil, nil, false
+	}
+
+	if f.ctx == nil {
+		f.ctx, f.cancel = context.WithCancel(context.Background())
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+	stop := context.AfterFunc(f.ctx, cancel)
+	f.wg.Add(1)
+
+	return ctx, func() {
+		stop()
+		cancel()
+		f.wg.Done()
+	}, true
+}
+
+// stop makes start refuse new handlers and signals the running ones
+func (f *inflight) stop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.stopped {
+		f.stopped = true
+		close(f.stoppingLocked())
+	}
+}
+
+// signal returns a channel that is closed once the bot starts shutting down
+func (f *inflight) signal() <-chan struct{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.stoppingLocked()
+}
+
+func (f *inflight) stoppingLocked() chan struct{} {
+	if f.stopping == nil {
+		f.stopping = make(chan struct{})
+	}
+
+	return f.stopping
+}
+
+// abort cancels the contexts of the running handlers
+func (f *inflight) abort() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.cancel != nil {
+		f.cancel()
+	}
+}
+
+// isStopped checks if the bot is shutting down
+func (f *inflight) isStopped() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.stopped
+}
+
+// wait blocks until all handlers finished or the context is done
+func (f *inflight) wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		f.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ShuttingDown returns a channel that is closed as soon as Shutdown is
+// called, handlers can watch it to wrap up early while the contexts of their
+// conversations are still valid
+func (b *Bot) ShuttingDown() <-chan struct{} {
+	return b.router.inflight.signal()
+}
+
+// Shutdown stops accepting new events and waits for the handlers of events
+// already received to finish. Events received over socket mode afterwards are
+// not acknowledged and HTTP requests are answered with 503, so Slack retries
+// them. ShuttingDown is closed right away. Then it waits until the queued
+// messages were posted. The contexts of the conversations are cancelled once
+// the handlers finished or ctx is done, in which case ctx's error is returned
+// and messages still queued fail. Either way messages sent afterwards fail
+// with ErrShutdown. Listen's context should be cancelled afterwards.
+func (b *Bot) Shutdown(ctx context.Context) error {
+	b.router.inflight.stop()
+	if err := b.router.inflight.wait(ctx); err != nil {
+		// Handlers still running must not post once their contexts are done
+		b.outbox.abort()
+		b.router.inflight.abort()
+		return err
+	}
+	b.router.inflight.abort()
+
+	return b.outbox.flush(ctx)
+}
@@ -0,0 +1,171 @@
+package hanu_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ChrisMcKee/hanu"
+	"github.com/ChrisMcKee/hanu/hanutest"
+	"github.com/slack-go/slack/slackevents"
+)
+
+func TestShutdownDrainsHandlers(t *testing.T) {
+	h := hanutest.New(t)
+
+	release := make(chan struct{})
+	h.Bot.Command("slow", func(conv hanu.Convo) {
+		<-release
+		conv.Reply("done")
+	})
+
+	h.Message("U1", "D1", "slow")
+
+	stopped := make(chan error)
+	go func() {
+		stopped <- h.Bot.Shutdown(context.Background())
+	}()
+
+	select {
+	case <-stopped:
+		t.Fatalf("Shutdown should wait for the running handler")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	env := h.Event(&slackevents.MessageEvent{
+		Type:        string(slackevents.Message),
+		User:        "U1",
+		Text:        "slow",
+		TimeStamp:   "1700000001.000000",
+		Channel:     "D1",
+		ChannelType: "im",
+	})
+	if _, ok := env.Acked(100 * time.Millisecond); ok {
+		t.Errorf("events received while shutting down should not be acknowledged")
+	}
+
+	close(release)
+
+	if err := <-stopped; err != nil {
+		t.Errorf("Shutdown should succeed, got %v", err)
+	}
+
+	if post := h.NextPost(); post.Text != "done" {
+		t.Errorf("reply should be \"done\", is \"%s\"", post.Text)
+	}
+	h.NoPost(100 * time.Millisecond)
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	h := hanutest.New(t)
+	replies := make(chan error, 1)
+	h.Bot.Command("wait", func(conv hanu.Convo) {
+		<-conv.Context().Done()
+		replies <- conv.Reply("cancelled").Wait()
+	})
+
+	h.Message("U1", "D1", "wait")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if err := h.Bot.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown should return the deadline error, got %v", err)
+	}
+
+	if err := <-replies; !errors.Is(err, hanu.ErrShutdown) {
+		t.Errorf("the handler's context should be cancelled and its reply fail, got %v", err)
+	}
+	h.NoPost(100 * time.Millisecond)
+}
+
+func TestShutdownDeadlineClosesOutbox(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.SetRateLimit(time.Hour, 1)
+
+	release := make(chan struct{})
+	late := make(chan error, 1)
+	h.Bot.Command("slow", func(conv hanu.Convo) {
+		<-release
+		late <- conv.Reply("late").Wait()
+	})
+
+	h.Message("U1", "D1", "slow")
+	h.Bot.Say("C1", "first")
+	h.NextPost()
+
+	queued := make(chan error, 1)
+	go func() {
+		queued <- h.Bot.Say("C1", "queued").Wait()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := h.Bot.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown should return the deadline error, got %v", err)
+	}
+
+	select {
+	case err := <-queued:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("messages still queued should fail, got %v", err)
+		}
+	case <-time.After(hanutest.Timeout):
+		t.Error("messages still queued should fail once Shutdown returns")
+	}
+
+	close(release)
+	if err := <-late; !errors.Is(err, hanu.ErrShutdown) {
+		t.Errorf("messages sent after shutting down should fail, got %v", err)
+	}
+	h.NoPost(100 * time.Millisecond)
+}
+
+func TestShutdownRejectsHTTPRequests(t *testing.T) {
+	h := hanutest.New(t)
+	handler := h.Bot.HTTPHandler(signingSecret)
+
+	if err := h.Bot.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown should succeed, got %v", err)
+	}
+
+	r := hanutest.NewSignedRequest(signingSecret, "application/json", []byte(`{"type":"url_verification","challenge":"abc"}`))
+	if w := serve(handler, r); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("requests should be rejected while shutting down, got %d", w.Code)
+	}
+}
+
+func TestShutdownSignalsHandlers(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.Command("watch", func(conv hanu.Convo) {
+		select {
+		case <-h.Bot.ShuttingDown():
+		case <-time.After(hanutest.Timeout):
+			t.Error("handler should be signalled as soon as Shutdown is called")
+		}
+
+		if err := conv.Context().Err(); err != nil {
+			t.Errorf("conversation's context should still be valid, got %v", err)
+		}
+		conv.Reply("stopping")
+	})
+
+	h.Message("U1", "D1", "watch")
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), hanutest.Timeout)
+	defer cancel()
+	if err := h.Bot.Shutdown(ctx); err != nil {
+		t.Fatalf("handler should finish before the deadline, got %v", err)
+	}
+
+	if post := h.NextPost(); post.Text != "stopping" {
+		t.Errorf("handler's reply should be posted, got \"%s\"", post.Text)
+	}
+
+	if err := h.Bot.Say("D1", "too late").Wait(); !errors.Is(err, hanu.ErrShutdown) {
+		t.Errorf("messages sent after shutting down should fail, got %v", err)
+	}
+}
@@ -0,0 +1,263 @@
+package hanu
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ChrisMcKee/allot"
+)
+
+// commandWords returns the literal words a command starts with, i.e. the
+// words before the first word containing a parameter. A word like
+// deploy<service> is not literal, the parameter continues it.
+func commandWords(cmd CommandInterface) []string {
+	var words []string
+	for _, word := range strings.Fields(cmd.Get().Text()) {
+		if strings.Contains(word, "<") {
+			break
+		}
+
+		words = append(words, word)
+	}
+
+	return words
+}
+
+// matchedWords counts how many of the command's literal words the text
+// starts with
+func matchedWords(cmd CommandInterface, text string) int {
+	words := commandWords(cmd)
+	fields := strings.Fields(text)
+
+	n := 0
+	for n < len(words) && n < len(fields) && words[n] == fields[n] {
+		n++
+	}
+
+	return n
+}
+
+// hasWordCount checks if the text has as many words as the command expects,
+// allot's optional whitespace would otherwise split words to fill parameters
+func hasWordCount(cmd CommandInterface, text string) bool {
+	words := strings.Fields(cmd.Get().Text())
+	optional := 0
+	for _, param := range cmd.Get().Parameters() {
+		if strings.HasSuffix(param.Data(), "?") {
+			optional++
+		}
+	}
+
+	n := len(strings.Fields(text))
+	return n >= len(words)-optional && n <= len(words)
+}
+
+// isOption checks if a parameter lists its valid values, e.g. <test|prod|dev>
+func isOption(param allot.Parameter) bool {
+	return strings.Contains(param.Name(), "|")
+}
+
+// invalidOption returns the first option parameter whose value is not one of
+// the listed values
+func invalidOption(cmd CommandInterface, match allot.MatchInterface) (allot.Parameter, string, bool) {
+	for _, param := range cmd.Get().Parameters() {
+		if !isOption(param) {
+			continue
+		}
+
+		value, err := match.Parameter(param)
+		if err != nil {
+			continue
+		}
+
+		if !contains(strings.Split(param.Name(), "|"), value) {
+			return param, value, true
+		}
+	}
+
+	return allot.Parameter{}, "", false
+}
+
+// helpTopic returns what help was requested for, e.g. "deploy" for both
+// "help deploy" and "deploy help"
+func helpTopic(text string) string {
+	text = strings.TrimSpace(text)
+	text = strings.TrimPrefix(text, "help")
+	text = strings.TrimSuffix(text, "help")
+
+	return strings.TrimSpace(text)
+}
+
+// BuildCommandHelpText will build the detailed help of a command including
+// its parameter descriptions and examples
+func (b *Bot) BuildCommandHelpText(cmd CommandInterface) string {
+	help := "`" + cmd.Get().Text() + "`"
+	if cmd.Description() != "" {
+		help = help + " *–* " + cmd.Description()
+	}
+	help = help + "\n"
+
+	described, ok := cmd.(interface {
+		ParameterDescription(name string) string
+		Examples() []string
+	})
+
+	for _, param := range cmd.Get().Parameters() {
+		text := ""
+		if ok {
+			text = described.ParameterDescription(param.Name())
+		}
+
+		if isOption(param) {
+			if text != "" {
+				text = text + ", "
+			}
+			text = text + "one of " + strings.Join(strings.Split(param.Name(), "|"), ", ")
+		}
+
+		if text == "" {
+			continue
+		}
+
+		help = help + "  • `" + param.Name() + "` – " + text + "\n"
+	}
+
+	if ok && len(described.Examples()) > 0 {
+		help = help + "Examples:\n"
+		for _, example := range described.Examples() {
+			help = help + "  `" + example + "`\n"
+		}
+	}
+
+	return help
+}
+
+// buildUsageText will build the detailed help of several commands
+func (b *Bot) buildUsageText(cmds []CommandInterface) string {
+	help := ""
+	for i, cmd := range cmds {
+		if i > 0 {
+			help = help + "\n"
+		}
+		help = help + b.BuildCommandHelpText(cmd)
+	}
+
+	return help
+}
+
+// commandsFor returns the commands the help topic refers to, which the
+// sender of the message is allowed to run
+func (b *Bot) commandsFor(ctx context.Context, topic string, msg MessageInterface) []CommandInterface {
+	topicWords := strings.Fields(strings.TrimPrefix(topic, b.CmdPrefix))
+	members := b.groupMembers(ctx)
+
+	var cmds []CommandInterface
+	for _, cmd := range b.Commands {
+		words := commandWords(cmd)
+		if len(words) < len(topicWords) {
+			continue
+		}
+
+		matches := true
+		for i, word := range topicWords {
+			if strings.TrimPrefix(words[i], b.CmdPrefix) != word {
+				matches = false
+				break
+			}
+		}
+
+		if matches && b.isListed(cmd, msg, members) {
+			cmds = append(cmds, cmd)
+		}
+	}
+
+	return cmds
+}
+
+// nearMatches returns the commands whose leading words match the most
+// leading words of a message that did not match any command
+func (b *Bot) nearMatches(ctx context.Context, msg MessageInterface) []CommandInterface {
+	members := b.groupMembers(ctx)
+
+	var cmds []CommandInterface
+	best := 0
+	for _, cmd := range b.Commands {
+		n := matchedWords(cmd, msg.Text())
+		if n == 0 || n < best || !b.isListed(cmd, msg, members) {
+			continue
+		}
+
+		if n > best {
+			best = n
+			cmds = nil
+		}
+		cmds = append(cmds, cmd)
+	}
+
+	return cmds
+}
+
+// suggestions returns the commands whose first word is closest to the first
+// word of a message that did not match any command
+func (b *Bot) suggestions(ctx context.Context, msg MessageInterface) []CommandInterface {
+	fields := strings.Fields(msg.Text())
+	if len(fields) == 0 {
+		return nil
+	}
+
+	members := b.groupMembers(ctx)
+
+	var cmds []CommandInterface
+	best := 3
+	for _, cmd := range b.Commands {
+		words := commandWords(cmd)
+		if len(words) == 0 {
+			continue
+		}
+
+		d := distance(strings.TrimPrefix(words[0], b.CmdPrefix), strings.TrimPrefix(fields[0], b.CmdPrefix))
+		if d == 0 || d > best || d >= len(words[0]) || !b.isListed(cmd, msg, members) {
+			continue
+		}
+
+		if d < best {
+			best = d
+			cmds = nil
+		}
+		cmds = append(cmds, cmd)
+	}
+
+	return cmds
+}
+
+// isCommandRequest checks if a message is clearly meant as a command for the
+// bot, so it deserves an answer even if it does not match one
+func (b *Bot) isCommandRequest(msg MessageInterface) bool {
+	return msg.IsRelevantFor(b.ID) || (b.CmdPrefix != "" && strings.HasPrefix(msg.Text(), b.CmdPrefix))
+}
+
+// distance returns the Levenshtein distance between two words
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
@@ -0,0 +1,110 @@
+package hanu
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	var data = []struct {
+		a string
+		b string
+		d int
+	}{
+		{"deploy", "deploy", 0},
+		{"deploy", "delpoy", 2},
+		{"deploy", "deplyo", 2},
+		{"deploy", "deploys", 1},
+		{"", "abc", 3},
+		{"ping", "pong", 1},
+	}
+
+	for _, set := range data {
+		if d := distance(set.a, set.b); d != set.d {
+			t.Errorf("distance(%s, %s) should be %d, is %d", set.a, set.b, set.d, d)
+		}
+	}
+}
+
+func TestHelpTopic(t *testing.T) {
+	var data = []struct {
+		in  string
+		out string
+	}{
+		{"help", ""},
+		{"help deploy", "deploy"},
+		{"deploy help", "deploy"},
+		{"help  deploy start ", "deploy start"},
+	}
+
+	for _, set := range data {
+		if topic := helpTopic(set.in); topic != set.out {
+			t.Errorf("helpTopic(%s) should be \"%s\", is \"%s\"", set.in, set.out, topic)
+		}
+	}
+}
+
+func TestCommandWords(t *testing.T) {
+	cmd := NewCommand("!deploy start <service> now", "", func(conv Convo) {})
+
+	if words := strings.Join(commandWords(cmd), " "); words != "!deploy start" {
+		t.Errorf("command words should be \"!deploy start\", are \"%s\"", words)
+	}
+
+	if n := matchedWords(cmd, "!deploy stop api"); n != 1 {
+		t.Errorf("one word should match, %d do", n)
+	}
+}
+
+func TestHasWordCount(t *testing.T) {
+	var data = []struct {
+		cmd   string
+		text  string
+		count bool
+	}{
+		{"deploy <service> <env>", "deploy api prod", true},
+		{"deploy <service> <env>", "deploy api", false},
+		{"deploy <service> <env>", "deploy api prod now", false},
+		{"deploy <service> <env:string?>", "deploy api", true},
+		{"deploy <service> <env:string?>", "deploy api prod", true},
+	}
+
+	for _, set := range data {
+		cmd := NewCommand(set.cmd, "", func(conv Convo) {})
+		if hasWordCount(cmd, set.text) != set.count {
+			t.Errorf("hasWordCount(%s, %s) should be %v", set.cmd, set.text, set.count)
+		}
+	}
+}
+
+func TestInvalidOption(t *testing.T) {
+	cmd := NewCommand("deploy <service> <staging|prod>", "", func(conv Convo) {})
+
+	match, _ := cmd.Get().Match("deploy api prod")
+	if _, _, invalid := invalidOption(cmd, match); invalid {
+		t.Errorf("prod should be a valid option")
+	}
+
+	match, _ = cmd.Get().Match("deploy api dev")
+	param, value, invalid := invalidOption(cmd, match)
+	if !invalid || value != "dev" || param.Name() != "staging|prod" {
+		t.Errorf("dev should be an invalid option, got %v %s %s", invalid, value, param.Name())
+	}
+}
+
+func TestBuildCommandHelpText(t *testing.T) {
+	cmd := NewCommand("deploy <service> <staging|prod>", "Deploy a service", func(conv Convo) {})
+	cmd.SetParameterDescription("service", "name of the service")
+	cmd.SetExamples("deploy api prod")
+
+	expected := "`deploy <service> <staging|prod>` *–* Deploy a service\n" +
+		"  • `service` – name of the service\n" +
+		"  • `staging|prod` – one of staging, prod\n" +
+		"Examples:\n" +
+		"  `deploy api prod`\n"
+
+	b := &Bot{}
+	if help := b.BuildCommandHelpText(cmd); help != expected {
+		t.Errorf("help should be\n%s\nis\n%s", expected, help)
+	}
+}
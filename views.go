@@ -0,0 +1,114 @@
+package hanu
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/socketmode"
+)
+
+// OpenView opens a modal in response to the interaction or command with the
+// given trigger ID
+func (b *Bot) OpenView(ctx context.Context, triggerID string, view slack.ModalViewRequest) (*slack.View, error) {
+	resp, err := b.SocketClient.OpenViewContext(ctx, triggerID, view)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp.View, nil
+}
+
+// PushView shows a view on top of the modal the interaction with the given
+// trigger ID was taken in
+func (b *Bot) PushView(ctx context.Context, triggerID string, view slack.ModalViewRequest) (*slack.View, error) {
+	resp, err := b.SocketClient.PushViewContext(ctx, triggerID, view)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp.View, nil
+}
+
+// UpdateView replaces the view with the given ID. If hash is set the update
+// fails if the view changed since it was received.
+func (b *Bot) UpdateView(ctx context.Context, view slack.ModalViewRequest, viewID, hash string) (*slack.View, error) {
+	resp, err := b.SocketClient.UpdateViewContext(ctx, view, "", hash, viewID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp.View, nil
+}
+
+// SetPrivateMetadata stores v encoded as JSON in the view, it is sent back
+// with every interaction with the view. Views set by the Update and Push
+// helpers of actions and submissions keep it unless they have their own,
+// Bot.UpdateView and Bot.PushView send the view as given.
+func SetPrivateMetadata(view *slack.ModalViewRequest, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	view.PrivateMetadata = string(data)
+	return nil
+}
+
+// PrivateMetadata decodes the metadata stored in the view with
+// SetPrivateMetadata into v
+func PrivateMetadata(view *slack.View, v interface{}) error {
+	if view == nil || view.PrivateMetadata == "" {
+		return nil
+	}
+
+	return json.Unmarshal([]byte(view.PrivateMetadata), v)
+}
+
+// keepMetadata carries the private metadata of the current view over to a
+// view replacing it or pushed on top of it, unless it has its own
+func keepMetadata(current *slack.View, view slack.ModalViewRequest) slack.ModalViewRequest {
+	if view.PrivateMetadata == "" && current != nil {
+		view.PrivateMetadata = current.PrivateMetadata
+	}
+
+	return view
+}
+
+// ViewClosed is a modal view the user closed, it is only sent for views
+// with NotifyOnClose set
+type ViewClosed struct {
+	// User is the user who closed the view
+	User string
+	// Cleared is set if all views of the modal were closed
+	Cleared bool
+
+	callback slack.InteractionCallback
+	ctx      context.Context
+}
+
+// CloseHandler handles a closed view
+type CloseHandler func(c *ViewClosed) error
+
+// OnClose adds the handler of closed views with the given callback ID
+func (b *Bot) OnClose(callbackID string, h CloseHandler) {
+	b.handleCallback(slack.InteractionTypeViewClosed, callbackID, func(ctx context.Context, cb slack.InteractionCallback, evt *socketmode.Event, client *socketmode.Client) error {
+		return h(&ViewClosed{User: cb.User.ID, Cleared: cb.IsCleared, callback: cb, ctx: ctx})
+	}, false)
+}
+
+// Context returns the context of the interaction, it is cancelled when the
+// bot stops listening or shuts down
+func (c *ViewClosed) Context() context.Context {
+	return c.ctx
+}
+
+// View returns the closed view
+func (c *ViewClosed) View() *slack.View {
+	return &c.callback.View
+}
+
+// Metadata decodes the private metadata of the closed view into v
+func (c *ViewClosed) Metadata(v interface{}) error {
+	return PrivateMetadata(c.View(), v)
+}
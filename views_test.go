@@ -0,0 +1,273 @@
+package hanu_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ChrisMcKee/hanu"
+	"github.com/ChrisMcKee/hanu/hanutest"
+	"github.com/slack-go/slack"
+)
+
+type deployState struct {
+	Service string `json:"service"`
+	Step    int    `json:"step"`
+}
+
+// viewRequest returns the view sent with the only call of the method
+func viewRequest(t *testing.T, h *hanutest.Harness, method string) slack.ModalViewRequest {
+	t.Helper()
+
+	calls := h.Server.Calls(method)
+	if len(calls) != 1 {
+		t.Fatalf("%s should be called once, was called %d times", method, len(calls))
+	}
+
+	var req struct {
+		View slack.ModalViewRequest `json:"view"`
+	}
+	if err := json.Unmarshal(calls[0].Body, &req); err != nil {
+		t.Fatalf("%s should be sent JSON: %v", method, err)
+	}
+
+	return req.View
+}
+
+func TestPrivateMetadata(t *testing.T) {
+	var view slack.ModalViewRequest
+	if err := hanu.SetPrivateMetadata(&view, deployState{Service: "api", Step: 2}); err != nil {
+		t.Fatalf("metadata should be encoded: %v", err)
+	}
+
+	var state deployState
+	if err := hanu.PrivateMetadata(&slack.View{PrivateMetadata: view.PrivateMetadata}, &state); err != nil {
+		t.Fatalf("metadata should be decoded: %v", err)
+	}
+	if state != (deployState{Service: "api", Step: 2}) {
+		t.Errorf("metadata should round trip, got %+v", state)
+	}
+
+	if err := hanu.PrivateMetadata(&slack.View{}, &state); err != nil {
+		t.Errorf("views without metadata should decode to nothing, got %v", err)
+	}
+}
+
+func TestOnClose(t *testing.T) {
+	h := hanutest.New(t)
+	closed := make(chan deployState, 1)
+	h.Bot.OnClose("deploy", func(c *hanu.ViewClosed) error {
+		if c.User != "U1" || !c.Cleared {
+			t.Errorf("view should be cleared by U1, got %+v", c)
+		}
+
+		var state deployState
+		if err := c.Metadata(&state); err != nil {
+			return err
+		}
+
+		closed <- state
+		return nil
+	})
+
+	var view slack.ModalViewRequest
+	hanu.SetPrivateMetadata(&view, deployState{Service: "api"})
+
+	h.Interaction(slack.InteractionCallback{
+		Type:               slack.InteractionTypeViewClosed,
+		User:               slack.User{ID: "U1"},
+		ViewClosedCallback: slack.ViewClosedCallback{IsCleared: true},
+		View:               slack.View{ID: "V1", CallbackID: "deploy", PrivateMetadata: view.PrivateMetadata},
+	}).Ack()
+
+	if state := <-closed; state.Service != "api" {
+		t.Errorf("closed view's metadata should be decoded, got %+v", state)
+	}
+}
+
+func TestDialogOnClose(t *testing.T) {
+	h := hanutest.New(t)
+	calls := make(chan string, 1)
+	h.Bot.RegisterModalInteraction(hanu.DialogCfg{
+		Type:       hanu.Modal,
+		CallbackId: "deploy",
+		OnClose:    hanu.DialogEvtHandler(routed(calls, "deploy closed")),
+	})
+
+	h.Interaction(slack.InteractionCallback{Type: slack.InteractionTypeViewClosed, User: slack.User{ID: "U1"}, View: slack.View{CallbackID: "U1deploy"}}).Ack()
+
+	if got := <-calls; got != "deploy closed" {
+		t.Errorf("closed modal should be handled, got %s", got)
+	}
+}
+
+func TestSubmissionKeepsMetadata(t *testing.T) {
+	h := hanutest.New(t)
+	h.Bot.OnSubmit("deploy", func(s *hanu.ViewSubmission) error {
+		s.Update(slack.ModalViewRequest{Type: slack.VTModal, CallbackID: "confirm"})
+		return nil
+	})
+
+	cb := submission("deploy", nil)
+	cb.View.PrivateMetadata = `{"service":"api"}`
+
+	resp := submitAck(t, h, cb)
+	if resp.View == nil || resp.View.PrivateMetadata != `{"service":"api"}` {
+		t.Errorf("updated view should keep the metadata, got %+v", resp.View)
+	}
+}
+
+func TestActionPushView(t *testing.T) {
+	h := hanutest.New(t)
+	errs := make(chan error, 1)
+	h.Bot.OnAction("next", func(a *hanu.Action) error {
+		var state deployState
+		if err := a.Metadata(&state); err != nil || state.Step != 1 {
+			t.Errorf("metadata should be step 1, got %+v, %v", state, err)
+		}
+
+		view := slack.ModalViewRequest{Type: slack.VTModal, CallbackID: "deploy_step2"}
+		hanu.SetPrivateMetadata(&view, deployState{Service: state.Service, Step: 2})
+		errs <- a.PushView(view)
+		return nil
+	})
+
+	h.Interaction(slack.InteractionCallback{
+		Type:      slack.InteractionTypeBlockActions,
+		TriggerID: "T1",
+		Container: slack.Container{Type: "view", ViewID: "V1"},
+		View:      slack.View{ID: "V1", CallbackID: "deploy", PrivateMetadata: `{"service":"api","step":1}`},
+		ActionCallback: slack.ActionCallbacks{BlockActions: []*slack.BlockAction{
+			{ActionID: "next"},
+		}},
+	}).Ack()
+
+	if err := <-errs; err != nil {
+		t.Fatalf("view should be pushed, got %v", err)
+	}
+
+	view := viewRequest(t, h, "views.push")
+	if view.CallbackID != "deploy_step2" || view.PrivateMetadata != `{"service":"api","step":2}` {
+		t.Errorf("pushed view should have its own metadata, got %+v", view)
+	}
+}
+
+func TestActionUpdateViewKeepsMetadata(t *testing.T) {
+	h := hanutest.New(t)
+	errs := make(chan error, 1)
+	h.Bot.OnAction("env", func(a *hanu.Action) error {
+		errs <- a.UpdateView(slack.ModalViewRequest{Type: slack.VTModal, CallbackID: "deploy_prod"})
+		return nil
+	})
+
+	h.Interaction(slack.InteractionCallback{
+		Type:      slack.InteractionTypeBlockActions,
+		Container: slack.Container{Type: "view", ViewID: "V1"},
+		View:      slack.View{ID: "V1", Hash: "h1", CallbackID: "deploy", PrivateMetadata: `{"service":"api"}`},
+		ActionCallback: slack.ActionCallbacks{BlockActions: []*slack.BlockAction{
+			{ActionID: "env"},
+		}},
+	}).Ack()
+
+	if err := <-errs; err != nil {
+		t.Fatalf("view should be updated, got %v", err)
+	}
+
+	if view := viewRequest(t, h, "views.update"); view.PrivateMetadata != `{"service":"api"}` {
+		t.Errorf("updated view should keep the metadata, got %+v", view)
+	}
+}
+
+// opened returns the views the bot opens
+func opened(h *hanutest.Harness) <-chan hanutest.Call {
+	calls := make(chan hanutest.Call, 1)
+	h.Server.Handle("views.open", func(c hanutest.Call) interface{} {
+		calls <- c
+		return map[string]interface{}{"ok": true}
+	})
+
+	return calls
+}
+
+// openedView returns the view the bot opens and the trigger ID it is opened with
+func openedView(t *testing.T, calls <-chan hanutest.Call) (slack.ModalViewRequest, string) {
+	t.Helper()
+
+	var req struct {
+		TriggerID string                 `json:"trigger_id"`
+		View      slack.ModalViewRequest `json:"view"`
+	}
+	select {
+	case c := <-calls:
+		if err := json.Unmarshal(c.Body, &req); err != nil {
+			t.Fatalf("views.open should be sent JSON: %v", err)
+		}
+	case <-time.After(hanutest.Timeout):
+		t.Fatal("modal should be opened")
+	}
+
+	return req.View, req.TriggerID
+}
+
+func deployModal() hanu.DialogCfg {
+	return hanu.DialogCfg{
+		Type:            hanu.Modal,
+		CallbackId:      "deploy",
+		SlashCommand:    "/deploy",
+		Shortcut:        "deploy_shortcut",
+		MessageShortcut: "deploy_message",
+		View: func(t *hanu.ModalTrigger) (slack.ModalViewRequest, error) {
+			view := slack.ModalViewRequest{Type: slack.VTModal}
+			err := hanu.SetPrivateMetadata(&view, map[string]string{"channel": t.Channel, "text": t.Text})
+			return view, err
+		},
+	}
+}
+
+func TestModalSlashCommand(t *testing.T) {
+	h := hanutest.New(t)
+	calls := opened(h)
+	h.Bot.RegisterModalInteraction(deployModal())
+
+	if _, ok := h.SlashCommand(slack.SlashCommand{Command: "/deploy", Text: "api", UserID: "U1", ChannelID: "C1", TriggerID: "T1"}).Acked(hanutest.Timeout); !ok {
+		t.Error("slash command should be acknowledged")
+	}
+
+	view, triggerID := openedView(t, calls)
+	if triggerID != "T1" || view.CallbackID != "U1deploy" || view.PrivateMetadata != `{"channel":"C1","text":"api"}` {
+		t.Errorf("modal should be opened for U1 with the command's trigger ID, got %s %+v", triggerID, view)
+	}
+}
+
+func TestModalShortcuts(t *testing.T) {
+	h := hanutest.New(t)
+	calls := opened(h)
+	h.Bot.RegisterModalInteraction(deployModal())
+
+	h.Interaction(slack.InteractionCallback{Type: slack.InteractionTypeShortcut, CallbackID: "deploy_shortcut", User: slack.User{ID: "U1"}, TriggerID: "T1"}).Ack()
+	if view, triggerID := openedView(t, calls); triggerID != "T1" || view.CallbackID != "U1deploy" {
+		t.Errorf("global shortcut should open the modal, got %s %+v", triggerID, view)
+	}
+
+	h.Interaction(slack.InteractionCallback{
+		Type:       slack.InteractionTypeMessageAction,
+		CallbackID: "deploy_message",
+		User:       slack.User{ID: "U2"},
+		Channel:    slack.Channel{GroupConversation: slack.GroupConversation{Conversation: slack.Conversation{ID: "C2"}}},
+		TriggerID:  "T2",
+	}).Ack()
+	if view, triggerID := openedView(t, calls); triggerID != "T2" || view.CallbackID != "U2deploy" || view.PrivateMetadata != `{"channel":"C2","text":""}` {
+		t.Errorf("message shortcut should open the modal in its channel, got %s %+v", triggerID, view)
+	}
+}
+
+func TestDialogCannotBeBound(t *testing.T) {
+	h := hanutest.New(t)
+	defer func() {
+		if recover() == nil {
+			t.Error("binding a dialog to a slash command should panic")
+		}
+	}()
+
+	h.Bot.RegisterDialogInteraction(hanu.DialogCfg{Type: hanu.Dialog, CallbackId: "deploy", SlashCommand: "/deploy"})
+}